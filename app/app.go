@@ -0,0 +1,102 @@
+// Package app wires the config, repositories and usecases together, so the
+// HTTP server and the CLI commands run on exactly the same setup.
+package app
+
+import (
+	"database/sql"
+	"net"
+	"roxy/config"
+	"roxy/repository"
+	"roxy/shared/notifier"
+	"roxy/usecase"
+
+	_ "github.com/lib/pq"
+)
+
+type App struct {
+	Config *config.Config
+	DB     *sql.DB
+
+	BarangUc     usecase.MstBarangUseCase
+	TransaksiUc  usecase.TransaksiUsecase
+	StockUc      usecase.StockMovementUseCase
+	ReturUc      usecase.ReturUsecase
+	PaymentUc    usecase.PaymentUsecase
+	ShiftUc      usecase.ShiftUsecase
+	SupplierUc   usecase.SupplierUseCase
+	PenerimaanUc usecase.PenerimaanUsecase
+	POUc         usecase.PurchaseOrderUsecase
+	OpnameUc     usecase.StockOpnameUsecase
+	ReportUc     usecase.ReportUsecase
+	AlertUc      usecase.StockAlertUsecase
+	UserUc       usecase.UserUsecase
+	AuthUc       usecase.AuthUsecase
+	AuditUc      usecase.AuditUsecase
+}
+
+// New reads the config and opens the database. The connection is made on
+// first use, so New works without a reachable database.
+func New() (*App, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(cfg.Driver, cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	//inject dependencies repo layer
+	barangRepo := repository.NewBarangRepository(db)
+	transaksiRepo := repository.NewTransaksiRepository(db)
+	stockRepo := repository.NewStockMovementRepository(db)
+	returRepo := repository.NewReturRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	shiftRepo := repository.NewShiftRepository(db)
+	supplierRepo := repository.NewSupplierRepository(db)
+	penerimaanRepo := repository.NewPenerimaanRepository(db)
+	poRepo := repository.NewPurchaseOrderRepository(db)
+	opnameRepo := repository.NewStockOpnameRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	alertRepo := repository.NewStockAlertRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	//inject dependencies usecase layer
+	return &App{
+		Config: cfg,
+		DB:     db,
+
+		BarangUc:     usecase.NewBarangUseCase(barangRepo, cfg.CostingMethod),
+		TransaksiUc:  usecase.NewTransaksiUsecase(transaksiRepo, barangRepo, cfg.PajakPersen),
+		StockUc:      usecase.NewStockMovementUseCase(stockRepo, barangRepo),
+		ReturUc:      usecase.NewReturUsecase(returRepo, transaksiRepo),
+		PaymentUc:    usecase.NewPaymentUsecase(paymentRepo, transaksiRepo),
+		ShiftUc:      usecase.NewShiftUsecase(shiftRepo),
+		SupplierUc:   usecase.NewSupplierUseCase(supplierRepo),
+		PenerimaanUc: usecase.NewPenerimaanUsecase(penerimaanRepo, supplierRepo, barangRepo, poRepo, cfg.POReceiveTolerance),
+		POUc:         usecase.NewPurchaseOrderUsecase(poRepo, supplierRepo, barangRepo),
+		OpnameUc:     usecase.NewStockOpnameUsecase(opnameRepo, barangRepo),
+		ReportUc:     usecase.NewReportUsecase(reportRepo),
+		AlertUc:      usecase.NewStockAlertUsecase(alertRepo, newNotifier(cfg.NotifierConfig)),
+		UserUc:       usecase.NewUserUsecase(userRepo),
+		AuthUc:       usecase.NewAuthUsecase(userRepo, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL),
+		AuditUc:      usecase.NewAuditUsecase(auditRepo),
+	}, nil
+}
+
+func (a *App) Close() error {
+	return a.DB.Close()
+}
+
+func newNotifier(cfg config.NotifierConfig) notifier.Notifier {
+	switch cfg.Notifier {
+	case "webhook":
+		return notifier.NewWebhookNotifier(cfg.WebhookURL)
+	case "email":
+		return notifier.NewEmailNotifier(net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort), cfg.SMTPFrom, cfg.AlertTo)
+	default:
+		return notifier.NewLogNotifier()
+	}
+}
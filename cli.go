@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"roxy/entity"
+)
+
+// cliActor is who the audit log shows for changes made from the command
+// line, there is no logged in user.
+func cliActor() entity.User {
+	actor := entity.User{Username: "cli"}
+	if u, err := user.Current(); err == nil {
+		actor.Username = "cli:" + u.Username
+	}
+	return actor
+}
+
+// newFlagSet returns a flag set for the command that prints its arguments
+// on -h.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(os.Stderr, "usage: roxy %s %s\n", cmd.name, cmd.args)
+			}
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,15 +17,65 @@ type DBConfig struct {
 	Password string
 	Name     string
 	Driver   string
+	// MigrateOnStart applies pending migrations before the server starts
+	MigrateOnStart bool
+}
+
+func (c DBConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		c.Host, c.Port, c.User, c.Password, c.Name)
 }
 
 type ApiConfig struct {
 	ApiPort string
 }
 
+type AuthConfig struct {
+	// JWTSecret signs the access tokens
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// AdminUsername and AdminPassword create the first admin while there are
+	// no users yet
+	AdminUsername string
+	AdminPassword string
+}
+
+type PurchaseConfig struct {
+	// POReceiveTolerance is how many percent above the ordered qty may still
+	// be received on a purchase order line
+	POReceiveTolerance int
+}
+
+type SalesConfig struct {
+	// PajakPersen is the tax in percent charged on the subtotal after diskon
+	PajakPersen int
+}
+
+type InventoryConfig struct {
+	// CostingMethod is the metode_hpp given to new barang that do not choose
+	// one themselves, either average or fifo
+	CostingMethod string
+}
+
+type NotifierConfig struct {
+	// Notifier is where stock alerts go: log, webhook or email
+	Notifier   string
+	WebhookURL string
+	SMTPHost   string
+	SMTPPort   string
+	SMTPFrom   string
+	AlertTo    []string
+}
+
 type Config struct {
 	DBConfig
 	ApiConfig
+	AuthConfig
+	PurchaseConfig
+	SalesConfig
+	InventoryConfig
+	NotifierConfig
 }
 
 func (c *Config) readConfig() error {
@@ -38,9 +91,93 @@ func (c *Config) readConfig() error {
 		Name:     os.Getenv("DB_NAME"),
 		Driver:   os.Getenv("DB_DRIVER"),
 	}
+	if v := os.Getenv("MIGRATE_ON_START"); v != "" {
+		migrate, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid MIGRATE_ON_START %q", v)
+		}
+		c.DBConfig.MigrateOnStart = migrate
+	}
+
+	c.ApiConfig = ApiConfig{
+		ApiPort: os.Getenv("API_PORT"),
+	}
+
+	c.AuthConfig = AuthConfig{
+		JWTSecret:       os.Getenv("JWT_SECRET"),
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 7 * 24 * time.Hour,
+		AdminUsername:   os.Getenv("ADMIN_USERNAME"),
+		AdminPassword:   os.Getenv("ADMIN_PASSWORD"),
+	}
+	if len(c.JWTSecret) < 32 {
+		return fmt.Errorf("JWT_SECRET must be at least 32 characters")
+	}
+	if v := os.Getenv("ACCESS_TOKEN_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ACCESS_TOKEN_TTL %q", v)
+		}
+		c.AuthConfig.AccessTokenTTL = ttl
+	}
+	if v := os.Getenv("REFRESH_TOKEN_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid REFRESH_TOKEN_TTL %q", v)
+		}
+		c.AuthConfig.RefreshTokenTTL = ttl
+	}
+
+	if v := os.Getenv("PO_RECEIVE_TOLERANCE"); v != "" {
+		tolerance, err := strconv.Atoi(v)
+		if err != nil || tolerance < 0 {
+			return fmt.Errorf("invalid PO_RECEIVE_TOLERANCE %q", v)
+		}
+		c.PurchaseConfig.POReceiveTolerance = tolerance
+	}
+
+	if v := os.Getenv("PAJAK_PERSEN"); v != "" {
+		pajak, err := strconv.Atoi(v)
+		if err != nil || pajak < 0 || pajak > 100 {
+			return fmt.Errorf("invalid PAJAK_PERSEN %q", v)
+		}
+		c.SalesConfig.PajakPersen = pajak
+	}
 
-	c.ApiConfig = ApiConfig{ApiPort: os.Getenv("API_PORT")}
+	c.InventoryConfig.CostingMethod = os.Getenv("COSTING_METHOD")
+	switch c.InventoryConfig.CostingMethod {
+	case "":
+		c.InventoryConfig.CostingMethod = "average"
+	case "average", "fifo":
+	default:
+		return fmt.Errorf("invalid COSTING_METHOD %q", c.InventoryConfig.CostingMethod)
+	}
 
+	c.NotifierConfig = NotifierConfig{
+		Notifier:   os.Getenv("NOTIFIER"),
+		WebhookURL: os.Getenv("ALERT_WEBHOOK_URL"),
+		SMTPHost:   os.Getenv("SMTP_HOST"),
+		SMTPPort:   os.Getenv("SMTP_PORT"),
+		SMTPFrom:   os.Getenv("SMTP_FROM"),
+	}
+	if v := os.Getenv("ALERT_EMAIL_TO"); v != "" {
+		c.NotifierConfig.AlertTo = strings.Split(v, ",")
+	}
+	switch c.NotifierConfig.Notifier {
+	case "":
+		c.NotifierConfig.Notifier = "log"
+	case "log":
+	case "webhook":
+		if c.WebhookURL == "" {
+			return fmt.Errorf("missing ALERT_WEBHOOK_URL for webhook notifier")
+		}
+	case "email":
+		if c.SMTPHost == "" || c.SMTPPort == "" || c.SMTPFrom == "" || len(c.AlertTo) == 0 {
+			return fmt.Errorf("missing SMTP_HOST, SMTP_PORT, SMTP_FROM or ALERT_EMAIL_TO for email notifier")
+		}
+	default:
+		return fmt.Errorf("invalid NOTIFIER %q", c.NotifierConfig.Notifier)
+	}
 
 	if c.Host == "" || c.Port == "" || c.User == "" || c.Name == "" || c.Driver == "" || c.ApiPort == "" {
 		return fmt.Errorf("missing required environment")
@@ -2,16 +2,78 @@ package config
 
 const (
 	ApiGroup = "/api/v1"
+	// auth route
+	PostLogin   = "/auth/login"
+	PostRefresh = "/auth/refresh"
+	PostLogout  = "/auth/logout"
+	GetMe       = "/auth/me"
+	// user route
+	PostUser    = "/user"
+	GetUserList = "/users"
+	GetUser     = "/user/:id"
+	PutUser     = "/user/:id"
 	// barang route
-	PostBarang    = "/barang"
-	GetBarangList = "/barangs"
-	GetBarang     = "/barang/:id"
-	PutBarang     = "/barang/:id"
-	DeleteBarang  = "/barang/:id"
+	PostBarang         = "/barang"
+	GetBarangList      = "/barangs"
+	GetLowStock        = "/barangs/low-stock"
+	SearchBarang       = "/barangs/search"
+	GetBarangByBarcode = "/barangs/barcode/:code"
+	GetBarang          = "/barang/:id"
+	PutBarang          = "/barang/:id"
+	DeleteBarang       = "/barang/:id"
 	// barang route
 	PostTransaksi    = "/transaksi"
 	GetTransaksiList = "/transaksis"
 	GetTransaksiByID = "/transaksi/:id"
 	PutTransaksi     = "/transaksi/:id"
 	DeleteTransaksi  = "/transaksi/:id"
+	VoidTransaksi    = "/transaksi/:id/void"
+	// payment route
+	PostPayment    = "/transaksi/:id/payments"
+	GetPaymentList = "/transaksi/:id/payments"
+	// retur route
+	PostRetur    = "/transaksi/:id/returns"
+	GetReturList = "/transaksi/:id/returns"
+	GetRetur     = "/transaksi/:id/returns/:idRetur"
+	// shift route
+	PostShift      = "/shifts"
+	GetOpenShift   = "/shifts/current"
+	CloseShift     = "/shifts/:id/close"
+	GetShiftReport = "/shifts/:id/report"
+	// supplier route
+	PostSupplier    = "/supplier"
+	GetSupplierList = "/suppliers"
+	GetSupplier     = "/supplier/:id"
+	PutSupplier     = "/supplier/:id"
+	DeleteSupplier  = "/supplier/:id"
+	// penerimaan route
+	PostPenerimaan    = "/penerimaan"
+	GetPenerimaanList = "/penerimaans"
+	GetPenerimaan     = "/penerimaan/:id"
+	// purchase order route
+	PostPurchaseOrder    = "/purchase-order"
+	GetPurchaseOrderList = "/purchase-orders"
+	GetPurchaseOrder     = "/purchase-order/:id"
+	SendPurchaseOrder    = "/purchase-order/:id/send"
+	CancelPurchaseOrder  = "/purchase-order/:id/cancel"
+	// stock opname route
+	PostStockOpname         = "/stock-opname"
+	GetStockOpnameList      = "/stock-opnames"
+	GetStockOpname          = "/stock-opname/:id"
+	PutStockOpnameCounts    = "/stock-opname/:id/counts"
+	UploadStockOpnameCounts = "/stock-opname/:id/counts/upload"
+	ApproveStockOpname      = "/stock-opname/:id/approve"
+	CancelStockOpname       = "/stock-opname/:id/cancel"
+	// report route
+	GetValuationReport     = "/reports/valuation"
+	GetGrossProfitReport   = "/reports/gross-profit"
+	GetSalesReport         = "/reports/sales"
+	GetTopItemsReport      = "/reports/top-items"
+	GetSlowMoversReport    = "/reports/slow-movers"
+	GetReplenishmentReport = "/reports/replenishment"
+	// audit route
+	GetAuditList = "/audit"
+	// stock movement route
+	PostStockMovement    = "/barang/:id/stock-movements"
+	GetStockMovementList = "/barang/:id/stock-movements"
 )
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditVoid   = "void"
+	AuditDelete = "delete"
+)
+
+const (
+	AuditBarang    = "barang"
+	AuditTransaksi = "transaksi"
+)
+
+var AuditEntities = []string{AuditBarang, AuditTransaksi}
+
+// AuditLog is one change to a barang or transaksi. Before is null for a
+// create and After is null for a delete.
+type AuditLog struct {
+	IDAudit   int64           `json:"id_audit"`
+	IDUser    string          `json:"id_user"`
+	Username  string          `json:"username"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows the audit log. From is inclusive and To is exclusive,
+// zero times and empty strings are not applied.
+type AuditFilter struct {
+	Page
+	Entity   string
+	EntityID string
+	IDUser   string
+	From     time.Time
+	To       time.Time
+}
+
+// TransaksiSnapshot is how a transaksi is recorded in the audit log.
+type TransaksiSnapshot struct {
+	Header TransaksiHeader   `json:"header"`
+	Detail []TransaksiDetail `json:"detail"`
+}
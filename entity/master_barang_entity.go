@@ -1,8 +1,58 @@
 package entity
 
+import "roxy/shared/money"
+
 type Barang struct {
-	Id_barang string  `json:"id_barang"`
-	Nm_barang string  `json:"nm_barang"`
-	Qty       int     `json:"qty"`
-	Harga     float32 `json:"harga"`
+	Id_barang          string      `json:"id_barang"`
+	Nm_barang          string      `json:"nm_barang"`
+	Sku                string      `json:"sku"`
+	Barcodes           []string    `json:"barcodes"`
+	Kategori           string      `json:"kategori"`
+	Qty                int         `json:"qty"`
+	Harga              money.Money `json:"harga"`
+	AllowNegativeStock bool        `json:"allow_negative_stock"`
+	HargaPokok         money.Money `json:"harga_pokok"`
+	MetodeHpp          string      `json:"metode_hpp"`
+	ReorderPoint       int         `json:"reorder_point"`
+	ReorderQty         int         `json:"reorder_qty"`
+	LeadTimeHari       int         `json:"lead_time_hari"`
+	SafetyStock        int         `json:"safety_stock"`
+}
+
+// BarangUpdate is a partial update of a barang. Zero values in Barang keep
+// what is stored, the pointer fields are nil when they are left out.
+type BarangUpdate struct {
+	Barang
+	AllowNegativeStock *bool `json:"allow_negative_stock"`
+	ReorderPoint       *int  `json:"reorder_point"`
+	ReorderQty         *int  `json:"reorder_qty"`
+	LeadTimeHari       *int  `json:"lead_time_hari"`
+	SafetyStock        *int  `json:"safety_stock"`
+}
+
+// BarangFilter narrows the barang list. Nil bounds are not applied, Nama
+// matches any part of nm_barang regardless of case.
+type BarangFilter struct {
+	Page
+	Nama     string
+	Kategori string
+	MinHarga *money.Money
+	MaxHarga *money.Money
+	MinQty   *int
+	MaxQty   *int
+	SortBy   string
+	Desc     bool
 }
+
+var BarangSortColumns = []string{"id_barang", "nm_barang", "kategori", "qty", "harga"}
+
+// BarangSearchResult is a barang matched by a search along with how well it
+// matched, higher is better.
+type BarangSearchResult struct {
+	Barang
+	Skor float64 `json:"skor"`
+}
+
+// BarangCSVColumns is the layout of roxy export barang, which roxy
+// import-barang reads back. Barcodes are separated by "|".
+var BarangCSVColumns = []string{"id_barang", "nm_barang", "sku", "kategori", "qty", "harga", "harga_pokok", "metode_hpp", "reorder_point", "reorder_qty", "lead_time_hari", "safety_stock", "barcodes"}
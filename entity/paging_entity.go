@@ -0,0 +1,17 @@
+package entity
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Page is the offset pagination of the list endpoints. Page starts at 1.
+type Page struct {
+	Page  int
+	Limit int
+}
+
+// Offset is the number of rows skipped before the page.
+func (p Page) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
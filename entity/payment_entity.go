@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+const (
+	PaymentCash        = "cash"
+	PaymentDebitCard   = "debit_card"
+	PaymentQRIS        = "qris"
+	PaymentEWallet     = "e_wallet"
+	PaymentStoreCredit = "store_credit"
+)
+
+const (
+	PaymentUnpaid        = "unpaid"
+	PaymentPartiallyPaid = "partially_paid"
+	PaymentPaid          = "paid"
+)
+
+type Payment struct {
+	IDPayment string      `json:"id_payment"`
+	IDTrans   string      `json:"id_trans"`
+	IDShift   string      `json:"id_shift"`
+	Metode    string      `json:"metode"`
+	Jumlah    money.Money `json:"jumlah"`
+	Kembalian money.Money `json:"kembalian"`
+	Referensi string      `json:"referensi"`
+	CreatedAt time.Time   `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+type PenerimaanHeader struct {
+	IDPenerimaan  string      `json:"id_penerimaan"`
+	IDSupplier    string      `json:"id_supplier"`
+	IDPO          string      `json:"id_po,omitempty"`
+	TglPenerimaan time.Time   `json:"tgl_penerimaan"`
+	NoFaktur      string      `json:"no_faktur"`
+	Total         money.Money `json:"total"`
+}
+
+type PenerimaanDetail struct {
+	IDPenerimaanDetail string      `json:"id_penerimaan_detail"`
+	IDPenerimaan       string      `json:"id_penerimaan"`
+	IDPODetail         string      `json:"id_po_detail,omitempty"`
+	IDBarang           string      `json:"id_barang"`
+	Qty                int         `json:"qty"`
+	HargaBeli          money.Money `json:"harga_beli"`
+	Subtotal           money.Money `json:"subtotal"`
+	OverReceipt        bool        `json:"over_receipt"`
+}
+
+type PenerimaanFilter struct {
+	IDSupplier string
+	From       time.Time
+	To         time.Time
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+const (
+	PODraft             = "draft"
+	POSent              = "sent"
+	POPartiallyReceived = "partially_received"
+	POReceived          = "received"
+	POCancelled         = "cancelled"
+)
+
+type PurchaseOrderHeader struct {
+	IDPO       string      `json:"id_po"`
+	IDSupplier string      `json:"id_supplier"`
+	TglPO      time.Time   `json:"tgl_po"`
+	Status     string      `json:"status"`
+	Catatan    string      `json:"catatan"`
+	Total      money.Money `json:"total"`
+}
+
+type PurchaseOrderDetail struct {
+	IDPODetail     string      `json:"id_po_detail"`
+	IDPO           string      `json:"id_po"`
+	IDBarang       string      `json:"id_barang"`
+	Qty            int         `json:"qty"`
+	QtyDiterima    int         `json:"qty_diterima"`
+	QtyOutstanding int         `json:"qty_outstanding"`
+	HargaBeli      money.Money `json:"harga_beli"`
+	Subtotal       money.Money `json:"subtotal"`
+}
+
+type PurchaseOrderFilter struct {
+	IDSupplier string
+	Status     string
+}
@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+// Groupings for the gross profit report.
+const (
+	ReportByItem     = "item"
+	ReportByKategori = "kategori"
+	ReportByDay      = "day"
+	ReportByWeek     = "week"
+	ReportByMonth    = "month"
+)
+
+type GrossProfitFilter struct {
+	From     time.Time
+	To       time.Time
+	GroupBy  string
+	SortBy   string
+	Desc     bool
+	Timezone string
+}
+
+// GrossProfitRow is one group of the gross profit report, net of returns.
+// Grup is the id_barang, the kategori or the first day of the period,
+// depending on how the report is grouped.
+type GrossProfitRow struct {
+	Grup         string      `json:"grup"`
+	Nama         string      `json:"nama"`
+	Qty          int         `json:"qty"`
+	Pendapatan   money.Money `json:"pendapatan"`
+	Hpp          money.Money `json:"hpp"`
+	LabaKotor    money.Money `json:"laba_kotor"`
+	MarginPersen float64     `json:"margin_persen"`
+}
+
+var GrossProfitColumns = []string{"grup", "nama", "qty", "pendapatan", "hpp", "laba_kotor", "margin_persen"}
+
+// Intervals for the sales summary.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+type SalesSummaryFilter struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+	Timezone string
+}
+
+// SalesSummaryRow totals the active transaksi of one period. Bruto is the sum
+// of the lines before diskon, Neto is what the customers were charged.
+type SalesSummaryRow struct {
+	Periode         string      `json:"periode"`
+	JumlahTransaksi int         `json:"jumlah_transaksi"`
+	JumlahItem      int         `json:"jumlah_item"`
+	Bruto           money.Money `json:"bruto"`
+	Diskon          money.Money `json:"diskon"`
+	Pajak           money.Money `json:"pajak"`
+	Neto            money.Money `json:"neto"`
+	RataRata        money.Money `json:"rata_rata"`
+	RataRataItem    float64     `json:"rata_rata_item"`
+}
+
+type TopItemFilter struct {
+	From   time.Time
+	To     time.Time
+	SortBy string
+	Limit  int
+}
+
+// TopItemRow ranks a barang by what it sold in the window, net of returns.
+type TopItemRow struct {
+	Peringkat  int         `json:"peringkat"`
+	IDBarang   string      `json:"id_barang"`
+	NmBarang   string      `json:"nm_barang"`
+	Qty        int         `json:"qty"`
+	Pendapatan money.Money `json:"pendapatan"`
+}
+
+// SlowMoverRow is a barang with stock on hand that has not sold since
+// TerakhirTerjual, which is nil when it never sold.
+type SlowMoverRow struct {
+	IDBarang        string      `json:"id_barang"`
+	NmBarang        string      `json:"nm_barang"`
+	Qty             int         `json:"qty"`
+	NilaiStok       money.Money `json:"nilai_stok"`
+	TerakhirTerjual *time.Time  `json:"terakhir_terjual"`
+}
+
+// ReplenishmentInput is what the planner knows about one barang: stock, what
+// is still outstanding on purchase orders and what sold in the history window.
+type ReplenishmentInput struct {
+	IDBarang     string
+	NmBarang     string
+	Qty          int
+	DalamPesanan int
+	Terjual      int
+	LeadTimeHari int
+	SafetyStock  int
+	ReorderQty   int
+	HargaPokok   money.Money
+}
+
+// ReplenishmentRow is a proposed reorder for a barang expected to fall below
+// its safety stock before a new order could arrive and cover the window.
+type ReplenishmentRow struct {
+	IDBarang        string      `json:"id_barang"`
+	NmBarang        string      `json:"nm_barang"`
+	Qty             int         `json:"qty"`
+	DalamPesanan    int         `json:"dalam_pesanan"`
+	RataRataHarian  float64     `json:"rata_rata_harian"`
+	LeadTimeHari    int         `json:"lead_time_hari"`
+	SafetyStock     int         `json:"safety_stock"`
+	HariSampaiHabis *float64    `json:"hari_sampai_habis"`
+	SaranPesan      int         `json:"saran_pesan"`
+	EstimasiBiaya   money.Money `json:"estimasi_biaya"`
+}
+
+var ReplenishmentColumns = []string{"id_barang", "nm_barang", "qty", "dalam_pesanan", "rata_rata_harian", "lead_time_hari", "safety_stock", "hari_sampai_habis", "saran_pesan", "estimasi_biaya"}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+// ReturHeader is a return of sold lines. Refunds are always paid in cash,
+// whatever the transaksi was paid with, out of the drawer of shift IDShift.
+type ReturHeader struct {
+	IDRetur     string      `json:"id_retur"`
+	IDTrans     string      `json:"id_trans"`
+	IDShift     string      `json:"id_shift"`
+	TglRetur    time.Time   `json:"tgl_retur"`
+	Alasan      string      `json:"alasan"`
+	TotalRefund money.Money `json:"total_refund"`
+}
+
+type ReturDetail struct {
+	IDReturDetail string      `json:"id_retur_detail"`
+	IDRetur       string      `json:"id_retur"`
+	IDTransDetail string      `json:"id_trans_detail"`
+	IDBarang      string      `json:"id_barang"`
+	Qty           int         `json:"qty"`
+	Harga         money.Money `json:"harga"`
+	Subtotal      money.Money `json:"subtotal"`
+	Hpp           money.Money `json:"hpp"`
+}
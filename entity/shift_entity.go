@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+type Shift struct {
+	IDShift     string      `json:"id_shift"`
+	Kasir       string      `json:"kasir"`
+	SaldoAwal   money.Money `json:"saldo_awal"`
+	KasDihitung money.Money `json:"kas_dihitung"`
+	Status      string      `json:"status"`
+	OpenedAt    time.Time   `json:"opened_at"`
+	ClosedAt    *time.Time  `json:"closed_at,omitempty"`
+}
+
+type PaymentMethodTotal struct {
+	Metode string      `json:"metode"`
+	Jumlah int         `json:"jumlah"`
+	Total  money.Money `json:"total"`
+}
+
+type ZReport struct {
+	Shift           Shift                `json:"shift"`
+	JumlahPenjualan int                  `json:"jumlah_penjualan"`
+	TotalPenjualan  money.Money          `json:"total_penjualan"`
+	JumlahVoid      int                  `json:"jumlah_void"`
+	TotalVoid       money.Money          `json:"total_void"`
+	JumlahRetur     int                  `json:"jumlah_retur"`
+	TotalRetur      money.Money          `json:"total_retur"`
+	PerMetode       []PaymentMethodTotal `json:"per_metode"`
+	KasDiharapkan   money.Money          `json:"kas_diharapkan"`
+	KasDihitung     money.Money          `json:"kas_dihitung"`
+	Selisih         money.Money          `json:"selisih"`
+}
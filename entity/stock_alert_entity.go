@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// StockAlert is queued when a movement takes the qty of a barang below its
+// reorder point, and delivered by the alert dispatcher.
+type StockAlert struct {
+	IDAlert      int64      `json:"id_alert"`
+	IDBarang     string     `json:"id_barang"`
+	NmBarang     string     `json:"nm_barang"`
+	IDMovement   int64      `json:"id_movement"`
+	Qty          int        `json:"qty"`
+	ReorderPoint int        `json:"reorder_point"`
+	ReorderQty   int        `json:"reorder_qty"`
+	Attempts     int        `json:"attempts"`
+	CreatedAt    time.Time  `json:"created_at"`
+	SentAt       *time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"fmt"
+	"roxy/shared/money"
+	"strings"
+	"time"
+)
+
+const (
+	MovementSale       = "sale"
+	MovementPurchase   = "purchase"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+	MovementTransfer   = "transfer"
+	MovementVoid       = "void"
+)
+
+// StockMovement carries the unit cost of the movement in HargaPokok. Nilai is
+// the signed cost value of the movement and NilaiSaldo the inventory value of
+// the barang right after it.
+type StockMovement struct {
+	IDMovement int64       `json:"id_movement"`
+	IDBarang   string      `json:"id_barang"`
+	Tipe       string      `json:"tipe"`
+	Qty        int         `json:"qty"`
+	Saldo      int         `json:"saldo"`
+	HargaPokok money.Money `json:"harga_pokok"`
+	Nilai      money.Money `json:"nilai"`
+	NilaiSaldo money.Money `json:"nilai_saldo"`
+	RefID      string      `json:"ref_id"`
+	Keterangan string      `json:"keterangan"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type InsufficientStock struct {
+	IDBarang  string `json:"id_barang"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+type InsufficientStockError struct {
+	Items []InsufficientStock
+}
+
+func (e *InsufficientStockError) Error() string {
+	items := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		items = append(items, fmt.Sprintf("%s (requested %d, available %d)", item.IDBarang, item.Requested, item.Available))
+	}
+	return "insufficient stock: " + strings.Join(items, ", ")
+}
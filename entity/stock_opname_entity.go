@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+const (
+	OpnameOpen      = "open"
+	OpnameApproved  = "approved"
+	OpnameCancelled = "cancelled"
+)
+
+// Reason codes recorded on the adjustments posted by an approved stock opname.
+const (
+	OpnameReasonCount      = "count"
+	OpnameReasonDamaged    = "damaged"
+	OpnameReasonLost       = "lost"
+	OpnameReasonExpired    = "expired"
+	OpnameReasonCorrection = "correction"
+)
+
+var OpnameReasons = []string{OpnameReasonCount, OpnameReasonDamaged, OpnameReasonLost, OpnameReasonExpired, OpnameReasonCorrection}
+
+type StockOpnameHeader struct {
+	IDOpname     string      `json:"id_opname"`
+	TglOpname    time.Time   `json:"tgl_opname"`
+	Status       string      `json:"status"`
+	Catatan      string      `json:"catatan"`
+	KodeAlasan   string      `json:"kode_alasan,omitempty"`
+	NilaiSelisih money.Money `json:"nilai_selisih"`
+	ApprovedAt   *time.Time  `json:"approved_at,omitempty"`
+}
+
+// StockOpnameDetail holds the system qty snapshotted when the opname was
+// opened. QtyHitung stays nil until the item has been counted.
+type StockOpnameDetail struct {
+	IDOpnameDetail string      `json:"id_opname_detail"`
+	IDOpname       string      `json:"id_opname"`
+	IDBarang       string      `json:"id_barang"`
+	QtySistem      int         `json:"qty_sistem"`
+	QtyHitung      *int        `json:"qty_hitung"`
+	Selisih        int         `json:"selisih"`
+	Harga          money.Money `json:"harga"`
+	NilaiSelisih   money.Money `json:"nilai_selisih"`
+}
+
+type StockOpnameCount struct {
+	IDBarang  string `json:"id_barang"`
+	QtyHitung int    `json:"qty_hitung"`
+}
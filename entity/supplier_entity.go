@@ -0,0 +1,8 @@
+package entity
+
+type Supplier struct {
+	IDSupplier string `json:"id_supplier"`
+	NmSupplier string `json:"nm_supplier"`
+	Alamat     string `json:"alamat"`
+	Telepon    string `json:"telepon"`
+}
@@ -1,20 +1,69 @@
 package entity
 
 import (
+	"roxy/shared/money"
 	"time"
 )
 
+const (
+	TransaksiActive = "active"
+	TransaksiVoided = "voided"
+)
+
 type TransaksiHeader struct {
-	IDTrans  string    `json:"id_trans"`
-	TglTrans time.Time `json:"tgl_trans"`
-	Total    float64   `json:"total"`
+	IDTrans     string      `json:"id_trans"`
+	IDShift     string      `json:"id_shift"`
+	TglTrans    time.Time   `json:"tgl_trans"`
+	Diskon      money.Money `json:"diskon"`
+	Pajak       money.Money `json:"pajak"`
+	Total       money.Money `json:"total"`
+	Dibayar     money.Money `json:"dibayar"`
+	Kembalian   money.Money `json:"kembalian"`
+	StatusBayar string      `json:"status_bayar"`
+	Status      string      `json:"status"`
+	VoidReason  string      `json:"void_reason,omitempty"`
+	VoidedAt    *time.Time  `json:"voided_at,omitempty"`
 }
 
 type TransaksiDetail struct {
-	IDTransDetail string  `json:"id_trans_detail"`
-	IDTrans       string  `json:"id_trans"`
-	IDBarang      string  `json:"id_barang"`
-	Qty           int     `json:"qty"`
-	Harga         float64 `json:"harga"`
-	Subtotal      float64 `json:"subtotal"`
+	IDTransDetail string      `json:"id_trans_detail"`
+	IDTrans       string      `json:"id_trans"`
+	IDBarang      string      `json:"id_barang"`
+	Barcode       string      `json:"barcode,omitempty"`
+	Qty           int         `json:"qty"`
+	Harga         money.Money `json:"harga"`
+	Subtotal      money.Money `json:"subtotal"`
+	Hpp           money.Money `json:"hpp"`
+}
+
+// TransaksiFilter narrows the transaksi list. From is inclusive and To is
+// exclusive, zero times and nil bounds are not applied.
+type TransaksiFilter struct {
+	Page
+	From     time.Time
+	To       time.Time
+	MinTotal *money.Money
+	MaxTotal *money.Money
+	Status   string
+	SortBy   string
+	Desc     bool
+}
+
+var TransaksiSortColumns = []string{"id_trans", "tgl_trans", "total"}
+
+type TransaksiSummary struct {
+	JumlahTransaksi int         `json:"jumlah_transaksi"`
+	Total           money.Money `json:"total"`
 }
+
+// TotalCorrection is a transaksi whose stored total did not add up to its
+// lines.
+type TotalCorrection struct {
+	IDTrans         string      `json:"id_trans"`
+	TotalLama       money.Money `json:"total_lama"`
+	TotalBaru       money.Money `json:"total_baru"`
+	StatusBayarLama string      `json:"status_bayar_lama"`
+	StatusBayarBaru string      `json:"status_bayar_baru"`
+}
+
+var TransaksiCSVColumns = []string{"id_trans", "id_shift", "tgl_trans", "status", "diskon", "pajak", "total", "dibayar", "kembalian", "status_bayar"}
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	RoleKasir      = "kasir"
+	RoleSupervisor = "supervisor"
+	RoleAdmin      = "admin"
+)
+
+// Roles runs from least to most privileged, a role may do everything the
+// roles before it may.
+var Roles = []string{RoleKasir, RoleSupervisor, RoleAdmin}
+
+type User struct {
+	IDUser       string    `json:"id_user"`
+	Username     string    `json:"username"`
+	Password     string    `json:"password,omitempty"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	Aktif        bool      `json:"aktif"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// HasRole reports whether the user is at least the given role.
+func (u User) HasRole(role string) bool {
+	min := slices.Index(Roles, role)
+	return min >= 0 && slices.Index(Roles, u.Role) >= min
+}
+
+// RefreshToken is kept only as a hash, a token can be used once and is
+// replaced by a new one on every refresh.
+type RefreshToken struct {
+	TokenHash string
+	IDUser    string
+	ExpiresAt time.Time
+}
+
+type AuthToken struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
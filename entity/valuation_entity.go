@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"roxy/shared/money"
+	"time"
+)
+
+// Costing methods for metode_hpp.
+const (
+	CostingAverage = "average"
+	CostingFIFO    = "fifo"
+)
+
+type ValuationItem struct {
+	IDBarang   string      `json:"id_barang"`
+	NmBarang   string      `json:"nm_barang"`
+	MetodeHpp  string      `json:"metode_hpp"`
+	Qty        int         `json:"qty"`
+	HargaPokok money.Money `json:"harga_pokok"`
+	Nilai      money.Money `json:"nilai"`
+}
+
+type InventoryValuation struct {
+	Tanggal    time.Time       `json:"tanggal"`
+	Items      []ValuationItem `json:"items"`
+	TotalNilai money.Money     `json:"total_nilai"`
+}
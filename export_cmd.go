@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"roxy/app"
+	"roxy/entity"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const exportUsage = "usage: roxy export [-o file] [-from date] [-to date] barang | transaksi"
+
+// runExport writes every barang, or the transaksi between -from and -to, as
+// CSV to stdout or the -o file.
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	fromFlag := fs.String("from", "", "only transaksi on or after this date (YYYY-MM-DD)")
+	toFlag := fs.String("to", "", "only transaksi up to and including this date (YYYY-MM-DD)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New(exportUsage)
+	}
+
+	var from, to time.Time
+	var err error
+	if *fromFlag != "" {
+		if from, err = time.Parse("2006-01-02", *fromFlag); err != nil {
+			return errors.New("-from must be a date (YYYY-MM-DD)")
+		}
+	}
+	if *toFlag != "" {
+		if to, err = time.Parse("2006-01-02", *toFlag); err != nil {
+			return errors.New("-to must be a date (YYYY-MM-DD)")
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	var export func(a *app.App, w *csv.Writer) error
+	switch fs.Arg(0) {
+	case "barang":
+		export = exportBarang
+	case "transaksi":
+		export = func(a *app.App, w *csv.Writer) error {
+			return exportTransaksi(a, w, from, to)
+		}
+	default:
+		return errors.New(exportUsage)
+	}
+
+	a, err := app.New()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := csv.NewWriter(out)
+	if err := export(a, w); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func exportBarang(a *app.App, w *csv.Writer) error {
+	if err := w.Write(entity.BarangCSVColumns); err != nil {
+		return err
+	}
+
+	filter := entity.BarangFilter{Page: entity.Page{Page: 1, Limit: entity.MaxPageLimit}}
+	for {
+		barangs, _, err := a.BarangUc.List(filter)
+		if err != nil {
+			return err
+		}
+		for _, b := range barangs {
+			err := w.Write([]string{
+				b.Id_barang, b.Nm_barang, b.Sku, b.Kategori, strconv.Itoa(b.Qty), b.Harga.String(), b.HargaPokok.String(), b.MetodeHpp,
+				strconv.Itoa(b.ReorderPoint), strconv.Itoa(b.ReorderQty), strconv.Itoa(b.LeadTimeHari), strconv.Itoa(b.SafetyStock),
+				strings.Join(b.Barcodes, "|"),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(barangs) < filter.Limit {
+			return nil
+		}
+		filter.Page.Page++
+	}
+}
+
+// exportTransaksi writes the headers, oldest first, including voided ones.
+func exportTransaksi(a *app.App, w *csv.Writer, from, to time.Time) error {
+	if err := w.Write(entity.TransaksiCSVColumns); err != nil {
+		return err
+	}
+
+	filter := entity.TransaksiFilter{
+		Page:   entity.Page{Page: 1, Limit: entity.MaxPageLimit},
+		From:   from,
+		To:     to,
+		SortBy: "tgl_trans",
+	}
+	for {
+		transaksi, _, err := a.TransaksiUc.GetAllTransaksi(filter)
+		if err != nil {
+			return err
+		}
+		for _, t := range transaksi {
+			err := w.Write([]string{
+				t.IDTrans, t.IDShift, t.TglTrans.Format(time.RFC3339), t.Status, t.Diskon.String(), t.Pajak.String(),
+				t.Total.String(), t.Dibayar.String(), t.Kembalian.String(), t.StatusBayar,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(transaksi) < filter.Limit {
+			return nil
+		}
+		filter.Page.Page++
+	}
+}
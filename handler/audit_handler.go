@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/common"
+	"roxy/shared/model"
+	"roxy/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditUc usecase.AuditUsecase
+	rg      *gin.RouterGroup
+}
+
+// listHandler takes page, limit, entity, entity_id, id_user and from/to,
+// newest first.
+func (a *AuditHandler) listHandler(ctx *gin.Context) {
+	filter := entity.AuditFilter{
+		Entity:   ctx.Query("entity"),
+		EntityID: ctx.Query("entity_id"),
+		IDUser:   ctx.Query("id_user"),
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseDateRange(ctx); err != nil {
+		ctx.Error(err)
+		return
+	}
+	if filter.Page, err = parsePage(ctx); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	logs, total, err := a.auditUc.List(filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendPagedResponseOk(ctx, logs, model.NewPaging(filter.Page.Page, filter.Limit, total), "Succes get audit log")
+}
+
+func (a *AuditHandler) Route() {
+	a.rg.GET(config.GetAuditList, RequireRole(entity.RoleAdmin), a.listHandler)
+}
+
+func NewAuditHandler(auditUc usecase.AuditUsecase, rg *gin.RouterGroup) *AuditHandler {
+	return &AuditHandler{auditUc: auditUc, rg: rg}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/shared/common"
+	"roxy/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	authUc usecase.AuthUsecase
+	rg     *gin.RouterGroup
+}
+
+func (a *AuthHandler) loginHandler(ctx *gin.Context) {
+	var payload struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	token, err := a.authUc.Login(payload.Username, payload.Password)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, token, "Login berhasil")
+}
+
+func (a *AuthHandler) refreshHandler(ctx *gin.Context) {
+	var payload struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	token, err := a.authUc.Refresh(payload.RefreshToken)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, token, "Token diperbarui")
+}
+
+func (a *AuthHandler) logoutHandler(ctx *gin.Context) {
+	var payload struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	if err := a.authUc.Logout(payload.RefreshToken); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, nil, "Logout berhasil")
+}
+
+// Route registers login, refresh and logout without authentication, a
+// client holding only a refresh token has no access token to send.
+func (a *AuthHandler) Route() {
+	a.rg.POST(config.PostLogin, a.loginHandler)
+	a.rg.POST(config.PostRefresh, a.refreshHandler)
+	a.rg.POST(config.PostLogout, a.logoutHandler)
+}
+
+func NewAuthHandler(authUc usecase.AuthUsecase, rg *gin.RouterGroup) *AuthHandler {
+	return &AuthHandler{authUc: authUc, rg: rg}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+	"roxy/usecase"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const userKey = "user"
+
+// Authenticate lets through requests carrying a valid access token in the
+// Authorization header and keeps its user for the handlers.
+func Authenticate(authUc usecase.AuthUsecase) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			common.SendAppError(ctx, apperror.Unauthorized("missing bearer token"))
+			return
+		}
+
+		user, err := authUc.Authenticate(token)
+		if err != nil {
+			common.SendAppError(ctx, toAppError(err))
+			return
+		}
+
+		ctx.Set(userKey, user)
+		ctx.Next()
+	}
+}
+
+// RequireRole guards an endpoint with the least role allowed to use it, see
+// entity.Roles for their order.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !currentUser(ctx).HasRole(role) {
+			common.SendAppError(ctx, apperror.Forbidden("%s role required", role))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// currentUser is the user of the access token, or no one on public routes.
+func currentUser(ctx *gin.Context) entity.User {
+	user, _ := ctx.Get(userKey)
+	u, _ := user.(entity.User)
+	return u
+}
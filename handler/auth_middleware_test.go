@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"roxy/entity"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		user   *entity.User // nil on a request without a user
+		role   string
+		status int
+	}{
+		{"kasir on a kasir route", &entity.User{Role: entity.RoleKasir}, entity.RoleKasir, http.StatusOK},
+		{"kasir on a supervisor route", &entity.User{Role: entity.RoleKasir}, entity.RoleSupervisor, http.StatusForbidden},
+		{"kasir on an admin route", &entity.User{Role: entity.RoleKasir}, entity.RoleAdmin, http.StatusForbidden},
+		{"supervisor on a kasir route", &entity.User{Role: entity.RoleSupervisor}, entity.RoleKasir, http.StatusOK},
+		{"supervisor on a supervisor route", &entity.User{Role: entity.RoleSupervisor}, entity.RoleSupervisor, http.StatusOK},
+		{"supervisor on an admin route", &entity.User{Role: entity.RoleSupervisor}, entity.RoleAdmin, http.StatusForbidden},
+		{"admin on a kasir route", &entity.User{Role: entity.RoleAdmin}, entity.RoleKasir, http.StatusOK},
+		{"admin on an admin route", &entity.User{Role: entity.RoleAdmin}, entity.RoleAdmin, http.StatusOK},
+		{"unknown role", &entity.User{Role: "owner"}, entity.RoleKasir, http.StatusForbidden},
+		{"route with an unknown role", &entity.User{Role: entity.RoleAdmin}, "owner", http.StatusForbidden},
+		{"no user", nil, entity.RoleKasir, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			router := gin.New()
+			router.GET("/", func(ctx *gin.Context) {
+				if tt.user != nil {
+					ctx.Set(userKey, *tt.user)
+				}
+			}, RequireRole(tt.role), func(ctx *gin.Context) {
+				reached = true
+				ctx.Status(http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if reached != (tt.status == http.StatusOK) {
+				t.Errorf("handler reached = %v, want %v", reached, tt.status == http.StatusOK)
+			}
+		})
+	}
+}
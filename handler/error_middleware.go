@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler sends the last error a handler reported with ctx.Error, unless
+// the handler already wrote a response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		common.SendAppError(ctx, toAppError(ctx.Errors.Last().Err))
+	}
+}
+
+// toAppError maps any error to the one sent to the client. Errors that are
+// not domain errors are logged and reported as internal errors.
+func toAppError(err error) *apperror.Error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		if appErr.Code == apperror.CodeInternal {
+			log.Printf("internal error: %v", appErr.Err)
+		}
+		return appErr
+	}
+
+	var stockErr *entity.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return &apperror.Error{
+			Code:    apperror.CodeInsufficientStock,
+			Message: stockErr.Error(),
+			Details: stockErr.Items,
+			Err:     err,
+		}
+	}
+
+	log.Printf("internal error: %v", err)
+	return apperror.Internal(err)
+}
+
+// bindJSON binds the request body into payload. On failure it reports the
+// error, naming the offending field when the body has the wrong type for it.
+func bindJSON(ctx *gin.Context, payload any) bool {
+	err := ctx.ShouldBindJSON(payload)
+	if err == nil {
+		return true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		ctx.Error(apperror.Invalid(typeErr.Field, "%s must be of type %s", typeErr.Field, typeErr.Type.Kind()))
+	case errors.Is(err, io.EOF):
+		ctx.Error(apperror.BadRequest("request body is empty"))
+	default:
+		ctx.Error(apperror.BadRequest("invalid request payload: %v", err))
+	}
+	return false
+}
@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"net/http"
 	"roxy/config"
 	"roxy/entity"
+	"roxy/shared/common"
+	"roxy/shared/model"
 	"roxy/usecase"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,73 +17,97 @@ type MasterBarangHandler struct {
 
 func (b *MasterBarangHandler) createHandler(ctx *gin.Context) {
 	var payload entity.Barang
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	barang, err := b.barangUc.Create(currentUser(ctx), payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
-	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		response := struct {
-			Message string
-			Data    entity.Barang
-		}{
-			Message: "Invalid Payload for Barang",
-			Data:    entity.Barang{},
-		}
+	common.SendSingleResponseCreated(ctx, barang, "Barang Created")
+}
 
-		ctx.JSON(http.StatusBadRequest, response)
+// listHandler takes page, limit, nama, kategori, min_harga, max_harga,
+// min_qty, max_qty, sort and order=asc|desc.
+func (b *MasterBarangHandler) listHandler(ctx *gin.Context) {
+	filter, err := parseBarangFilter(ctx)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
-	barang, err := b.barangUc.Create(payload)
+
+	barangs, total, err := b.barangUc.List(filter)
 	if err != nil {
-		response := struct {
-			Message string
-		}{
-			Message: err.Error(),
-		}
-		ctx.JSON(http.StatusInternalServerError, response)
+		ctx.Error(err)
 		return
 	}
 
-	response := struct {
-		Message string
-		Data    entity.Barang
-	}{
-		Message: "Barang Created",
-		Data:    barang,
+	if barangs == nil {
+		barangs = []entity.Barang{}
+	}
+	common.SendPagedResponseOk(ctx, barangs, model.NewPaging(filter.Page.Page, filter.Limit, total), "Succes get all barang")
+}
+
+func parseBarangFilter(ctx *gin.Context) (entity.BarangFilter, error) {
+	filter := entity.BarangFilter{
+		Nama:     ctx.Query("nama"),
+		Kategori: ctx.Query("kategori"),
+		SortBy:   ctx.Query("sort"),
+		Desc:     ctx.Query("order") == "desc",
+	}
+
+	var err error
+	if filter.Page, err = parsePage(ctx); err != nil {
+		return filter, err
+	}
+	if filter.MinHarga, err = optionalMoney(ctx, "min_harga"); err != nil {
+		return filter, err
+	}
+	if filter.MaxHarga, err = optionalMoney(ctx, "max_harga"); err != nil {
+		return filter, err
+	}
+	if filter.MinQty, err = optionalInt(ctx, "min_qty"); err != nil {
+		return filter, err
+	}
+	if filter.MaxQty, err = optionalInt(ctx, "max_qty"); err != nil {
+		return filter, err
 	}
 
-	ctx.JSON(http.StatusCreated, response)
+	return filter, nil
 }
 
-func (b *MasterBarangHandler) listHandler(ctx *gin.Context) {
+// searchHandler takes q and an optional limit, and returns the best matches
+// first.
+func (b *MasterBarangHandler) searchHandler(ctx *gin.Context) {
+	limit, err := optionalInt(ctx, "limit")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if limit == nil {
+		limit = new(int)
+	}
 
-	barangs, err := b.barangUc.List()
+	results, err := b.barangUc.Search(ctx.Query("q"), *limit)
 	if err != nil {
-		response := struct {
-			Message string
-		}{
-			Message: err.Error(),
-		}
-		ctx.JSON(http.StatusInternalServerError, response)
+		ctx.Error(err)
 		return
 	}
 
-	if len(barangs) > 0 {
-		response := struct {
-			Message string
-			Data    []entity.Barang
-		}{
-			Message: "Succes get all barang",
-			Data:    barangs,
-		}
+	common.SendSingleResponseOk(ctx, results, "Succes search barang")
+}
 
-		ctx.JSON(http.StatusOK, response)
+func (b *MasterBarangHandler) lowStockHandler(ctx *gin.Context) {
+	barangs, err := b.barangUc.ListLowStock()
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
-	response := struct {
-		Message string
-	}{
-		Message: "List of barang is empty",
-	}
 
-	ctx.JSON(http.StatusOK, response)
+	common.SendSingleResponseOk(ctx, barangs, "Succes get low stock barang")
 }
 
 func (b *MasterBarangHandler) getHandler(ctx *gin.Context) {
@@ -91,112 +115,65 @@ func (b *MasterBarangHandler) getHandler(ctx *gin.Context) {
 
 	barang, err := b.barangUc.GetByID(id)
 	if err != nil {
-		response := struct {
-			Message string
-		}{
-			Message: "Barang of Id " + id + " Not Found",
-		}
-
-		ctx.JSON(http.StatusNotFound, response)
+		ctx.Error(err)
 		return
 	}
-	response := struct {
-		Message string
-		Data    entity.Barang
-	}{
-		Message: "Succes get barang by id",
-		Data:    barang,
-	}
 
-	ctx.JSON(http.StatusOK, response)
+	common.SendSingleResponseOk(ctx, barang, "Barang of Id "+id)
 }
 
 func (b *MasterBarangHandler) updateHandler(ctx *gin.Context) {
 	id := ctx.Param("id")
-	var payload entity.Barang
 
-	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		response := struct {
-			Message string
-		}{
-			Message: "Invalid Payload for Barang",
-		}
-		ctx.JSON(http.StatusBadRequest, response)
+	var payload entity.BarangUpdate
+	if !bindJSON(ctx, &payload) {
 		return
 	}
 
 	payload.Id_barang = id
 
-	barang, err := b.barangUc.Update(payload)
+	barang, err := b.barangUc.Update(currentUser(ctx), payload)
 	if err != nil {
-		if strings.Contains(err.Error(), "name already exists") {
-			// Specific error for name conflict
-			response := struct {
-				Message string
-			}{
-				Message: err.Error(),
-			}
-			ctx.JSON(http.StatusConflict, response)
-			return
-		}
-
-		if strings.Contains(err.Error(), "not found") {
-			response := struct {
-				Message string
-			}{
-				Message: "Barang with ID " + id + " Not Found",
-			}
-			ctx.JSON(http.StatusNotFound, response)
-			return
-		}
-
-		response := struct {
-			Message string
-		}{
-			Message: "Failed to update barang: " + err.Error(),
-		}
-		ctx.JSON(http.StatusInternalServerError, response)
+		ctx.Error(err)
 		return
 	}
 
-	response := struct {
-		Message string
-		Data    entity.Barang
-	}{
-		Message: "Barang of Id " + id + " Updated",
-		Data:    barang,
+	common.SendSingleResponseOk(ctx, barang, "Barang of Id "+id+" Updated")
+}
+
+// barcodeHandler looks a barang up by any of its barcodes, for scanners.
+func (b *MasterBarangHandler) barcodeHandler(ctx *gin.Context) {
+	code := ctx.Param("code")
+
+	barang, err := b.barangUc.GetByBarcode(code)
+	if err != nil {
+		ctx.Error(err)
+		return
 	}
-	ctx.JSON(http.StatusOK, response)
+
+	common.SendSingleResponseOk(ctx, barang, "Barang of barcode "+code)
 }
 
 func (b *MasterBarangHandler) deleteHandler(ctx *gin.Context) {
 	id := ctx.Param("id")
-	err := b.barangUc.Delete(id)
-	if err != nil {
-		response := struct {
-			Message string
-		}{
-			Message: "Barang of Id " + id + " Not Found",
-		}
 
-		ctx.JSON(http.StatusNotFound, response)
+	if err := b.barangUc.Delete(currentUser(ctx), id); err != nil {
+		ctx.Error(err)
 		return
 	}
-	response := struct {
-		Message string
-	}{
-		Message: "Barang of Id " + id + " Deleted",
-	}
 
-	ctx.JSON(http.StatusOK, response)
+	common.SendSingleResponseOk(ctx, nil, "Barang of Id "+id+" Deleted")
 }
 
 func (b *MasterBarangHandler) Route() {
-	b.rg.POST(config.PostBarang, b.createHandler)
+	b.rg.POST(config.PostBarang, RequireRole(entity.RoleSupervisor), b.createHandler)
 	b.rg.GET(config.GetBarangList, b.listHandler)
+	b.rg.GET(config.GetLowStock, b.lowStockHandler)
+	b.rg.GET(config.SearchBarang, b.searchHandler)
+	b.rg.GET(config.GetBarangByBarcode, b.barcodeHandler)
 	b.rg.GET(config.GetBarang, b.getHandler)
-	b.rg.PUT(config.PutBarang, b.updateHandler)
-	b.rg.DELETE(config.DeleteBarang, b.deleteHandler)
+	b.rg.PUT(config.PutBarang, RequireRole(entity.RoleSupervisor), b.updateHandler)
+	b.rg.DELETE(config.DeleteBarang, RequireRole(entity.RoleAdmin), b.deleteHandler)
 }
 
 func NewBarangHandler(barangUc usecase.MstBarangUseCase, rg *gin.RouterGroup) *MasterBarangHandler {
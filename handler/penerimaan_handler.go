@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+	"roxy/usecase"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PenerimaanHandler struct {
+	penerimaanUc usecase.PenerimaanUsecase
+	rg           *gin.RouterGroup
+}
+
+func (p *PenerimaanHandler) createHandler(ctx *gin.Context) {
+	var req struct {
+		Header struct {
+			IDSupplier        string `json:"id_supplier"`
+			IDPO              string `json:"id_po"`
+			TanggalPenerimaan string `json:"tanggal_penerimaan"`
+			NoFaktur          string `json:"no_faktur"`
+		} `json:"header"`
+		Detail []entity.PenerimaanDetail `json:"detail"`
+	}
+
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	header := entity.PenerimaanHeader{
+		IDSupplier: req.Header.IDSupplier,
+		IDPO:       req.Header.IDPO,
+		NoFaktur:   req.Header.NoFaktur,
+	}
+	if req.Header.TanggalPenerimaan != "" {
+		tgl, err := time.Parse("2006-01-02", req.Header.TanggalPenerimaan)
+		if err != nil {
+			ctx.Error(apperror.Invalid("header.tanggal_penerimaan", "tanggal_penerimaan must be a date (YYYY-MM-DD)"))
+			return
+		}
+		header.TglPenerimaan = tgl
+	}
+
+	header, details, err := p.penerimaanUc.CreatePenerimaan(header, req.Detail)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, gin.H{"header": header, "detail": details}, "Penerimaan berhasil dibuat")
+}
+
+func (p *PenerimaanHandler) listHandler(ctx *gin.Context) {
+	from, to, err := parseDateRange(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filter := entity.PenerimaanFilter{
+		IDSupplier: ctx.Query("id_supplier"),
+		From:       from,
+		To:         to,
+	}
+
+	penerimaans, err := p.penerimaanUc.ListPenerimaan(filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, penerimaans, "Succes get all penerimaan")
+}
+
+func (p *PenerimaanHandler) getHandler(ctx *gin.Context) {
+	header, details, err := p.penerimaanUc.GetPenerimaanByID(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, gin.H{"header": header, "detail": details}, "Succes get penerimaan by id")
+}
+
+func (p *PenerimaanHandler) Route() {
+	p.rg.POST(config.PostPenerimaan, RequireRole(entity.RoleSupervisor), p.createHandler)
+	p.rg.GET(config.GetPenerimaanList, p.listHandler)
+	p.rg.GET(config.GetPenerimaan, p.getHandler)
+}
+
+func NewPenerimaanHandler(penerimaanUc usecase.PenerimaanUsecase, rg *gin.RouterGroup) *PenerimaanHandler {
+	return &PenerimaanHandler{penerimaanUc: penerimaanUc, rg: rg}
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+	"roxy/usecase"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseOrderHandler struct {
+	poUc usecase.PurchaseOrderUsecase
+	rg   *gin.RouterGroup
+}
+
+func (p *PurchaseOrderHandler) createHandler(ctx *gin.Context) {
+	var req struct {
+		Header struct {
+			IDSupplier string `json:"id_supplier"`
+			TanggalPO  string `json:"tanggal_po"`
+			Catatan    string `json:"catatan"`
+		} `json:"header"`
+		Detail []entity.PurchaseOrderDetail `json:"detail"`
+	}
+
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	header := entity.PurchaseOrderHeader{
+		IDSupplier: req.Header.IDSupplier,
+		Catatan:    req.Header.Catatan,
+	}
+	if req.Header.TanggalPO != "" {
+		tgl, err := time.Parse("2006-01-02", req.Header.TanggalPO)
+		if err != nil {
+			ctx.Error(apperror.Invalid("header.tanggal_po", "tanggal_po must be a date (YYYY-MM-DD)"))
+			return
+		}
+		header.TglPO = tgl
+	}
+
+	header, details, err := p.poUc.CreatePurchaseOrder(header, req.Detail)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, gin.H{"header": header, "detail": details}, "Purchase order berhasil dibuat")
+}
+
+func (p *PurchaseOrderHandler) listHandler(ctx *gin.Context) {
+	filter := entity.PurchaseOrderFilter{
+		IDSupplier: ctx.Query("id_supplier"),
+		Status:     ctx.Query("status"),
+	}
+
+	purchaseOrders, err := p.poUc.ListPurchaseOrder(filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, purchaseOrders, "Succes get all purchase order")
+}
+
+func (p *PurchaseOrderHandler) getHandler(ctx *gin.Context) {
+	header, details, err := p.poUc.GetPurchaseOrderByID(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, gin.H{"header": header, "detail": details}, "Succes get purchase order by id")
+}
+
+func (p *PurchaseOrderHandler) sendHandler(ctx *gin.Context) {
+	header, err := p.poUc.SendPurchaseOrder(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, header, "Purchase order dikirim")
+}
+
+func (p *PurchaseOrderHandler) cancelHandler(ctx *gin.Context) {
+	header, err := p.poUc.CancelPurchaseOrder(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, header, "Purchase order dibatalkan")
+}
+
+func (p *PurchaseOrderHandler) Route() {
+	supervisor := RequireRole(entity.RoleSupervisor)
+	p.rg.POST(config.PostPurchaseOrder, supervisor, p.createHandler)
+	p.rg.GET(config.GetPurchaseOrderList, p.listHandler)
+	p.rg.GET(config.GetPurchaseOrder, p.getHandler)
+	p.rg.POST(config.SendPurchaseOrder, supervisor, p.sendHandler)
+	p.rg.POST(config.CancelPurchaseOrder, supervisor, p.cancelHandler)
+}
+
+func NewPurchaseOrderHandler(poUc usecase.PurchaseOrderUsecase, rg *gin.RouterGroup) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{poUc: poUc, rg: rg}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseDateRange reads the optional from/to query params (YYYY-MM-DD). The
+// returned to is exclusive, i.e. the start of the day after the given date.
+func parseDateRange(ctx *gin.Context) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+
+	if v := ctx.Query("from"); v != "" {
+		from, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, apperror.Invalid("from", "from must be a date (YYYY-MM-DD)")
+		}
+	}
+	if v := ctx.Query("to"); v != "" {
+		to, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, apperror.Invalid("to", "to must be a date (YYYY-MM-DD)")
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return from, to, nil
+}
+
+// parsePage reads the optional page/limit query params, defaulting to the
+// first page of entity.DefaultPageLimit rows.
+func parsePage(ctx *gin.Context) (entity.Page, error) {
+	page := entity.Page{Page: 1, Limit: entity.DefaultPageLimit}
+	var err error
+
+	if v := ctx.Query("page"); v != "" {
+		if page.Page, err = strconv.Atoi(v); err != nil {
+			return page, apperror.Invalid("page", "page must be a number")
+		}
+	}
+	if v := ctx.Query("limit"); v != "" {
+		if page.Limit, err = strconv.Atoi(v); err != nil {
+			return page, apperror.Invalid("limit", "limit must be a number")
+		}
+	}
+
+	return page, nil
+}
+
+// optionalInt reads an integer query param, nil when it is absent.
+func optionalInt(ctx *gin.Context, name string) (*int, error) {
+	v := ctx.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, apperror.Invalid(name, "%s must be a number", name)
+	}
+	return &n, nil
+}
+
+// optionalMoney reads an amount query param, nil when it is absent.
+func optionalMoney(ctx *gin.Context, name string) (*money.Money, error) {
+	v := ctx.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	m, err := money.Parse(v)
+	if err != nil {
+		return nil, apperror.Invalid(name, "%s must be an amount", name)
+	}
+	return &m, nil
+}
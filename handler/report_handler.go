@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+	"roxy/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	reportUc usecase.ReportUsecase
+	rg       *gin.RouterGroup
+}
+
+// valuationHandler values the stock at the end of the day given in tanggal
+// (YYYY-MM-DD), or right now when tanggal is omitted.
+func (r *ReportHandler) valuationHandler(ctx *gin.Context) {
+	var asOf time.Time
+	if v := ctx.Query("tanggal"); v != "" {
+		tgl, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.Error(apperror.Invalid("tanggal", "tanggal must be a date (YYYY-MM-DD)"))
+			return
+		}
+		asOf = tgl.AddDate(0, 0, 1)
+	}
+
+	valuation, err := r.reportUc.InventoryValuation(asOf)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, valuation, "Succes get inventory valuation")
+}
+
+// grossProfitHandler takes from/to, group_by, sort, order=asc|desc and tz, an
+// IANA timezone for the days, weeks and months. With format=csv the report is
+// downloaded as a CSV file.
+func (r *ReportHandler) grossProfitHandler(ctx *gin.Context) {
+	from, to, err := parseDateRange(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filter := entity.GrossProfitFilter{
+		From:     from,
+		To:       to,
+		GroupBy:  ctx.Query("group_by"),
+		SortBy:   ctx.Query("sort"),
+		Desc:     ctx.Query("order") == "desc",
+		Timezone: ctx.Query("tz"),
+	}
+
+	report, err := r.reportUc.GrossProfit(filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if ctx.Query("format") == "csv" {
+		records := [][]string{entity.GrossProfitColumns}
+		for _, row := range report {
+			records = append(records, []string{
+				row.Grup,
+				row.Nama,
+				strconv.Itoa(row.Qty),
+				row.Pendapatan.String(),
+				row.Hpp.String(),
+				row.LabaKotor.String(),
+				strconv.FormatFloat(row.MarginPersen, 'f', 2, 64),
+			})
+		}
+		common.SendCSVResponse(ctx, "gross-profit.csv", records)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, report, "Succes get gross profit report")
+}
+
+// salesSummaryHandler takes from/to, interval=day|week|month and tz, an IANA
+// timezone such as Asia/Jakarta.
+func (r *ReportHandler) salesSummaryHandler(ctx *gin.Context) {
+	from, to, err := parseDateRange(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filter := entity.SalesSummaryFilter{
+		From:     from,
+		To:       to,
+		Interval: ctx.Query("interval"),
+		Timezone: ctx.Query("tz"),
+	}
+
+	report, err := r.reportUc.SalesSummary(filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, report, "Succes get sales summary")
+}
+
+// topItemsHandler takes from/to (the last 30 days when both are omitted),
+// sort=qty|pendapatan and limit.
+func (r *ReportHandler) topItemsHandler(ctx *gin.Context) {
+	from, to, err := parseDateRange(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filter := entity.TopItemFilter{
+		From:   from,
+		To:     to,
+		SortBy: ctx.Query("sort"),
+	}
+	if v := ctx.Query("limit"); v != "" {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			ctx.Error(apperror.Invalid("limit", "limit must be a number"))
+			return
+		}
+	}
+
+	report, err := r.reportUc.TopItems(filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, report, "Succes get top items")
+}
+
+// slowMoversHandler takes days, 90 when omitted.
+func (r *ReportHandler) slowMoversHandler(ctx *gin.Context) {
+	days := 90
+	if v := ctx.Query("days"); v != "" {
+		var err error
+		days, err = strconv.Atoi(v)
+		if err != nil {
+			ctx.Error(apperror.Invalid("days", "days must be a number"))
+			return
+		}
+	}
+
+	report, err := r.reportUc.SlowMovers(days)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, report, "Succes get slow moving items")
+}
+
+// replenishmentHandler takes history_days (30) and coverage_days (14). With
+// format=csv the proposal is downloaded as a CSV file.
+func (r *ReportHandler) replenishmentHandler(ctx *gin.Context) {
+	historyDays, coverageDays := 30, 14
+	var err error
+	if v := ctx.Query("history_days"); v != "" {
+		if historyDays, err = strconv.Atoi(v); err != nil {
+			ctx.Error(apperror.Invalid("history_days", "history_days must be a number"))
+			return
+		}
+	}
+	if v := ctx.Query("coverage_days"); v != "" {
+		if coverageDays, err = strconv.Atoi(v); err != nil {
+			ctx.Error(apperror.Invalid("coverage_days", "coverage_days must be a number"))
+			return
+		}
+	}
+
+	proposal, err := r.reportUc.Replenishment(historyDays, coverageDays)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if ctx.Query("format") == "csv" {
+		records := [][]string{entity.ReplenishmentColumns}
+		for _, row := range proposal {
+			hariSampaiHabis := ""
+			if row.HariSampaiHabis != nil {
+				hariSampaiHabis = strconv.FormatFloat(*row.HariSampaiHabis, 'f', 1, 64)
+			}
+			records = append(records, []string{
+				row.IDBarang,
+				row.NmBarang,
+				strconv.Itoa(row.Qty),
+				strconv.Itoa(row.DalamPesanan),
+				strconv.FormatFloat(row.RataRataHarian, 'f', 2, 64),
+				strconv.Itoa(row.LeadTimeHari),
+				strconv.Itoa(row.SafetyStock),
+				hariSampaiHabis,
+				strconv.Itoa(row.SaranPesan),
+				row.EstimasiBiaya.String(),
+			})
+		}
+		common.SendCSVResponse(ctx, "replenishment.csv", records)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, proposal, "Succes get replenishment proposal")
+}
+
+// Route puts every report behind the supervisor role, they show costs and
+// margins.
+func (r *ReportHandler) Route() {
+	supervisor := RequireRole(entity.RoleSupervisor)
+	r.rg.GET(config.GetValuationReport, supervisor, r.valuationHandler)
+	r.rg.GET(config.GetGrossProfitReport, supervisor, r.grossProfitHandler)
+	r.rg.GET(config.GetSalesReport, supervisor, r.salesSummaryHandler)
+	r.rg.GET(config.GetTopItemsReport, supervisor, r.topItemsHandler)
+	r.rg.GET(config.GetSlowMoversReport, supervisor, r.slowMoversHandler)
+	r.rg.GET(config.GetReplenishmentReport, supervisor, r.replenishmentHandler)
+}
+
+func NewReportHandler(reportUc usecase.ReportUsecase, rg *gin.RouterGroup) *ReportHandler {
+	return &ReportHandler{reportUc: reportUc, rg: rg}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+	"roxy/usecase"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReturHandler struct {
+	returUc usecase.ReturUsecase
+	rg      *gin.RouterGroup
+}
+
+func (r *ReturHandler) createHandler(ctx *gin.Context) {
+	var req struct {
+		TanggalRetur string               `json:"tanggal_retur"`
+		Alasan       string               `json:"alasan"`
+		Detail       []entity.ReturDetail `json:"detail"`
+	}
+
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	header := entity.ReturHeader{Alasan: req.Alasan}
+	if req.TanggalRetur != "" {
+		tglRetur, err := time.Parse("2006-01-02", req.TanggalRetur)
+		if err != nil {
+			ctx.Error(apperror.Invalid("tanggal_retur", "tanggal_retur must be a date (YYYY-MM-DD)"))
+			return
+		}
+		header.TglRetur = tglRetur
+	}
+
+	header, details, err := r.returUc.CreateRetur(currentUser(ctx), ctx.Param("id"), header, req.Detail)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, gin.H{"header": header, "detail": details}, "Retur berhasil dibuat")
+}
+
+func (r *ReturHandler) listHandler(ctx *gin.Context) {
+	idTrans := ctx.Param("id")
+
+	returs, err := r.returUc.ListReturByTransaksi(idTrans)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, returs, "Succes get retur of transaksi "+idTrans)
+}
+
+func (r *ReturHandler) getHandler(ctx *gin.Context) {
+	header, details, err := r.returUc.GetReturByID(ctx.Param("id"), ctx.Param("idRetur"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, gin.H{"header": header, "detail": details}, "Succes get retur by id")
+}
+
+func (r *ReturHandler) Route() {
+	r.rg.POST(config.PostRetur, RequireRole(entity.RoleSupervisor), r.createHandler)
+	r.rg.GET(config.GetReturList, r.listHandler)
+	r.rg.GET(config.GetRetur, r.getHandler)
+}
+
+func NewReturHandler(returUc usecase.ReturUsecase, rg *gin.RouterGroup) *ReturHandler {
+	return &ReturHandler{returUc: returUc, rg: rg}
+}
@@ -1,61 +1,58 @@
 package handler
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"roxy/app"
 	"roxy/config"
-	"roxy/repository"
-	"roxy/usecase"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/lib/pq"
 )
 
 type Server struct {
-	barangUc    usecase.MstBarangUseCase
-	transaksiUc usecase.TransaksiUsecase
+	app *app.App
 
 	engine *gin.Engine
 	host   string
 }
 
 func (s *Server) initRoute() {
-	rg := s.engine.Group(config.ApiGroup)
-
-	NewBarangHandler(s.barangUc, rg).Route()
-	NewTransaksiHandler(s.transaksiUc, rg).Route()
+	api := s.engine.Group(config.ApiGroup)
+	NewAuthHandler(s.app.AuthUc, api).Route()
+
+	// everything else needs a logged in user, the handlers require a
+	// higher role per endpoint where a kasir is not enough
+	rg := api.Group("", Authenticate(s.app.AuthUc))
+
+	NewUserHandler(s.app.UserUc, rg).Route()
+	NewBarangHandler(s.app.BarangUc, rg).Route()
+	NewTransaksiHandler(s.app.TransaksiUc, s.app.PaymentUc, rg).Route()
+	NewStockMovementHandler(s.app.StockUc, rg).Route()
+	NewReturHandler(s.app.ReturUc, rg).Route()
+	NewShiftHandler(s.app.ShiftUc, rg).Route()
+	NewSupplierHandler(s.app.SupplierUc, rg).Route()
+	NewPenerimaanHandler(s.app.PenerimaanUc, rg).Route()
+	NewPurchaseOrderHandler(s.app.POUc, rg).Route()
+	NewStockOpnameHandler(s.app.OpnameUc, rg).Route()
+	NewReportHandler(s.app.ReportUc, rg).Route()
+	NewAuditHandler(s.app.AuditUc, rg).Route()
 }
 
 func (s *Server) Run() {
 	s.initRoute()
+	go s.app.AlertUc.Run(context.Background(), 30*time.Second)
 	if err := s.engine.Run(s.host); err != nil {
 		panic(fmt.Errorf("server not running on host %s, becauce error %v", s.host, err.Error()))
 	}
 }
 
-func NewServer() *Server {
-	cfg, _ := config.NewConfig()
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name)
-
-	db, err := sql.Open(cfg.Driver, dsn)
-	if err != nil {
-		fmt.Println("connection error", err)
-	}
-
-	//inject dependencies repo layer
-	barangRepo := repository.NewBarangRepository(db)
-	transaksiRepo := repository.NewTransaksiRepository(db)
-	//inject dependencies usecase layer
-	barangUc := usecase.NewBarangUseCase(barangRepo)
-	transaksiUc := usecase.NewTransaksiUsecase(transaksiRepo, barangRepo)
-
+func NewServer(a *app.App) *Server {
 	engine := gin.Default()
-	host := fmt.Sprintf(":%s", cfg.ApiPort)
+	engine.Use(ErrorHandler())
+	host := fmt.Sprintf(":%s", a.Config.ApiPort)
 	return &Server{
-		barangUc:    barangUc,
-		transaksiUc: transaksiUc,
-
+		app:    a,
 		engine: engine,
 		host:   host,
 	}
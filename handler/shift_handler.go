@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/common"
+	"roxy/shared/money"
+	"roxy/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShiftHandler struct {
+	shiftUc usecase.ShiftUsecase
+	rg      *gin.RouterGroup
+}
+
+func (s *ShiftHandler) openHandler(ctx *gin.Context) {
+	var payload entity.Shift
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+	if payload.Kasir == "" {
+		payload.Kasir = currentUser(ctx).Username
+	}
+
+	shift, err := s.shiftUc.Open(currentUser(ctx), payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, shift, "Shift dibuka")
+}
+
+// currentHandler returns the open shift of the caller, or of ?kasir= for a
+// supervisor.
+func (s *ShiftHandler) currentHandler(ctx *gin.Context) {
+	kasir := ctx.Query("kasir")
+	if kasir == "" {
+		kasir = currentUser(ctx).Username
+	}
+
+	shift, err := s.shiftUc.GetOpen(currentUser(ctx), kasir)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, shift, "Succes get open shift")
+}
+
+func (s *ShiftHandler) closeHandler(ctx *gin.Context) {
+	var payload struct {
+		KasDihitung money.Money `json:"kas_dihitung"`
+	}
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	report, err := s.shiftUc.Close(currentUser(ctx), ctx.Param("id"), payload.KasDihitung)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, report, "Shift ditutup")
+}
+
+func (s *ShiftHandler) reportHandler(ctx *gin.Context) {
+	report, err := s.shiftUc.ZReport(currentUser(ctx), ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, report, "Succes get Z report")
+}
+
+func (s *ShiftHandler) Route() {
+	s.rg.POST(config.PostShift, s.openHandler)
+	s.rg.GET(config.GetOpenShift, s.currentHandler)
+	s.rg.POST(config.CloseShift, s.closeHandler)
+	s.rg.GET(config.GetShiftReport, s.reportHandler)
+}
+
+func NewShiftHandler(shiftUc usecase.ShiftUsecase, rg *gin.RouterGroup) *ShiftHandler {
+	return &ShiftHandler{shiftUc: shiftUc, rg: rg}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/common"
+	"roxy/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockMovementHandler struct {
+	stockUc usecase.StockMovementUseCase
+	rg      *gin.RouterGroup
+}
+
+func (s *StockMovementHandler) createHandler(ctx *gin.Context) {
+	var payload entity.StockMovement
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	payload.IDBarang = ctx.Param("id")
+
+	movement, err := s.stockUc.Create(payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, movement, "Stock Movement Created")
+}
+
+func (s *StockMovementHandler) listHandler(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	movements, err := s.stockUc.ListByBarang(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, movements, "Succes get stock movement of barang "+id)
+}
+
+func (s *StockMovementHandler) Route() {
+	s.rg.POST(config.PostStockMovement, RequireRole(entity.RoleSupervisor), s.createHandler)
+	s.rg.GET(config.GetStockMovementList, s.listHandler)
+}
+
+func NewStockMovementHandler(stockUc usecase.StockMovementUseCase, rg *gin.RouterGroup) *StockMovementHandler {
+	return &StockMovementHandler{stockUc: stockUc, rg: rg}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/csv"
+	"io"
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+	"roxy/usecase"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockOpnameHandler struct {
+	opnameUc usecase.StockOpnameUsecase
+	rg       *gin.RouterGroup
+}
+
+func (s *StockOpnameHandler) createHandler(ctx *gin.Context) {
+	var req struct {
+		Catatan  string   `json:"catatan"`
+		IDBarang []string `json:"id_barang"`
+	}
+
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	header, details, err := s.opnameUc.CreateStockOpname(req.Catatan, req.IDBarang)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, gin.H{"header": header, "detail": details}, "Stock opname berhasil dibuat")
+}
+
+func (s *StockOpnameHandler) listHandler(ctx *gin.Context) {
+	opnames, err := s.opnameUc.ListStockOpname()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, opnames, "Succes get all stock opname")
+}
+
+func (s *StockOpnameHandler) getHandler(ctx *gin.Context) {
+	header, details, err := s.opnameUc.GetStockOpnameByID(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, gin.H{"header": header, "detail": details}, "Succes get stock opname by id")
+}
+
+func (s *StockOpnameHandler) countsHandler(ctx *gin.Context) {
+	var counts []entity.StockOpnameCount
+
+	if !bindJSON(ctx, &counts) {
+		return
+	}
+
+	s.recordCounts(ctx, counts)
+}
+
+// uploadCountsHandler accepts a CSV file in the "file" form field with the
+// columns id_barang,qty_hitung. A header row is optional.
+func (s *StockOpnameHandler) uploadCountsHandler(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.Error(apperror.Invalid("file", "file CSV harus diupload pada field file"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	defer file.Close()
+
+	counts, err := parseOpnameCSV(file)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	s.recordCounts(ctx, counts)
+}
+
+func (s *StockOpnameHandler) recordCounts(ctx *gin.Context, counts []entity.StockOpnameCount) {
+	header, details, err := s.opnameUc.RecordCounts(ctx.Param("id"), counts)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, gin.H{"header": header, "detail": details}, "Hasil hitung berhasil disimpan")
+}
+
+func (s *StockOpnameHandler) approveHandler(ctx *gin.Context) {
+	var req struct {
+		KodeAlasan string `json:"kode_alasan"`
+	}
+
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	header, details, err := s.opnameUc.ApproveStockOpname(ctx.Param("id"), req.KodeAlasan)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, gin.H{"header": header, "detail": details}, "Stock opname disetujui")
+}
+
+func (s *StockOpnameHandler) cancelHandler(ctx *gin.Context) {
+	header, err := s.opnameUc.CancelStockOpname(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, header, "Stock opname dibatalkan")
+}
+
+func parseOpnameCSV(r io.Reader) ([]entity.StockOpnameCount, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var counts []entity.StockOpnameCount
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, apperror.Invalid("file", "CSV tidak valid: %v", err)
+		}
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "id_barang") {
+			continue
+		}
+
+		qty, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, apperror.Invalid("file", "CSV baris %d: qty_hitung %q bukan angka", line, record[1])
+		}
+		counts = append(counts, entity.StockOpnameCount{IDBarang: strings.TrimSpace(record[0]), QtyHitung: qty})
+	}
+
+	return counts, nil
+}
+
+func (s *StockOpnameHandler) Route() {
+	supervisor := RequireRole(entity.RoleSupervisor)
+	s.rg.POST(config.PostStockOpname, supervisor, s.createHandler)
+	s.rg.GET(config.GetStockOpnameList, s.listHandler)
+	s.rg.GET(config.GetStockOpname, s.getHandler)
+	s.rg.PUT(config.PutStockOpnameCounts, s.countsHandler)
+	s.rg.POST(config.UploadStockOpnameCounts, s.uploadCountsHandler)
+	s.rg.POST(config.ApproveStockOpname, RequireRole(entity.RoleAdmin), s.approveHandler)
+	s.rg.POST(config.CancelStockOpname, supervisor, s.cancelHandler)
+}
+
+func NewStockOpnameHandler(opnameUc usecase.StockOpnameUsecase, rg *gin.RouterGroup) *StockOpnameHandler {
+	return &StockOpnameHandler{opnameUc: opnameUc, rg: rg}
+}
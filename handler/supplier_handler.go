@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/common"
+	"roxy/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SupplierHandler struct {
+	supplierUc usecase.SupplierUseCase
+	rg         *gin.RouterGroup
+}
+
+func (s *SupplierHandler) createHandler(ctx *gin.Context) {
+	var payload entity.Supplier
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	supplier, err := s.supplierUc.Create(payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, supplier, "Supplier Created")
+}
+
+func (s *SupplierHandler) listHandler(ctx *gin.Context) {
+	suppliers, err := s.supplierUc.List()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, suppliers, "Succes get all supplier")
+}
+
+func (s *SupplierHandler) getHandler(ctx *gin.Context) {
+	supplier, err := s.supplierUc.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, supplier, "Succes get supplier by id")
+}
+
+func (s *SupplierHandler) updateHandler(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var payload entity.Supplier
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	payload.IDSupplier = id
+
+	supplier, err := s.supplierUc.Update(payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, supplier, "Supplier of Id "+id+" Updated")
+}
+
+func (s *SupplierHandler) deleteHandler(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	if err := s.supplierUc.Delete(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, nil, "Supplier of Id "+id+" Deleted")
+}
+
+func (s *SupplierHandler) Route() {
+	s.rg.POST(config.PostSupplier, RequireRole(entity.RoleSupervisor), s.createHandler)
+	s.rg.GET(config.GetSupplierList, s.listHandler)
+	s.rg.GET(config.GetSupplier, s.getHandler)
+	s.rg.PUT(config.PutSupplier, RequireRole(entity.RoleSupervisor), s.updateHandler)
+	s.rg.DELETE(config.DeleteSupplier, RequireRole(entity.RoleAdmin), s.deleteHandler)
+}
+
+func NewSupplierHandler(supplierUc usecase.SupplierUseCase, rg *gin.RouterGroup) *SupplierHandler {
+	return &SupplierHandler{supplierUc: supplierUc, rg: rg}
+}
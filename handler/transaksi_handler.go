@@ -1,9 +1,12 @@
 package handler
 
 import (
-	"net/http"
 	"roxy/config"
 	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/common"
+	"roxy/shared/model"
+	"roxy/shared/money"
 	"roxy/usecase"
 	"time"
 
@@ -12,64 +15,136 @@ import (
 
 type TransaksiHandler struct {
 	TransaksiUsecase usecase.TransaksiUsecase
+	PaymentUsecase   usecase.PaymentUsecase
 	rg               *gin.RouterGroup
 }
 
 func (t *TransaksiHandler) CreateTransaksiHandler(c *gin.Context) {
 	var req struct {
 		Header struct {
-			TanggalTransaksi string `json:"tanggal_transaksi"`
+			TanggalTransaksi string      `json:"tanggal_transaksi"`
+			Diskon           money.Money `json:"diskon"`
 		} `json:"header"`
-		Detail []entity.TransaksiDetail `json:"detail"`
+		Detail   []entity.TransaksiDetail `json:"detail"`
+		Payments []entity.Payment         `json:"payments"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	tglTrans, err := time.Parse("2006-01-02", req.Header.TanggalTransaksi)
+	tglTrans, err := parseTanggalTransaksi(req.Header.TanggalTransaksi)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		c.Error(err)
 		return
 	}
 
 	header := entity.TransaksiHeader{
 		TglTrans: tglTrans,
+		Diskon:   req.Header.Diskon,
+	}
+
+	idTransaksi, err := t.TransaksiUsecase.CreateTransaksiWithDetail(currentUser(c), header, req.Detail, req.Payments)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	idTransaksi, err := t.TransaksiUsecase.CreateTransaksiWithDetail(header, req.Detail)
+	created, _, err := t.TransaksiUsecase.GetTransaksiByID(idTransaksi)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	respone := gin.H{
-		"message": "Transaksi berhasil dibuat",
-		"data": gin.H{
-			"id_trans":          idTransaksi,
-			"tanggal_transaksi": tglTrans.Format("2006-01-02"),
-			"total":             header.Total,
-			"detail":            req.Detail,
-		},
+	data := gin.H{
+		"id_trans":          idTransaksi,
+		"tanggal_transaksi": created.TglTrans.Format("2006-01-02"),
+		"tgl_trans":         created.TglTrans,
+		"diskon":            created.Diskon,
+		"pajak":             created.Pajak,
+		"total":             created.Total,
+		"detail":            req.Detail,
+		"payments":          req.Payments,
+		"dibayar":           created.Dibayar,
+		"kembalian":         created.Kembalian,
+		"status_bayar":      created.StatusBayar,
 	}
 
-	c.JSON(http.StatusCreated, respone)
+	common.SendSingleResponseCreated(c, data, "Transaksi berhasil dibuat")
+}
+
+// parseTanggalTransaksi reads the optional tanggal_transaksi, a date
+// (YYYY-MM-DD) or a full RFC 3339 timestamp. An empty value gives the zero
+// time, which leaves the choice to the usecase.
+func parseTanggalTransaksi(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if tglTrans, err := time.Parse(time.RFC3339, v); err == nil {
+		return tglTrans, nil
+	}
+	tglTrans, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return tglTrans, apperror.Invalid("header.tanggal_transaksi", "tanggal_transaksi must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+	}
+	return tglTrans, nil
 }
 
+// GetAllTransaksiHandler takes page, limit, from/to, min_total, max_total,
+// status, sort and order=asc|desc, newest first by default. The summary covers
+// all active transaksi.
 func (t *TransaksiHandler) GetAllTransaksiHandler(c *gin.Context) {
-	transaksi, err := t.TransaksiUsecase.GetAllTransaksi()
+	filter, err := parseTransaksiFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	response := gin.H{
-		"message": "Succes get all transaksi",
-		"data":    transaksi,
+	transaksi, total, err := t.TransaksiUsecase.GetAllTransaksi(filter)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	summary, err := t.TransaksiUsecase.GetTransaksiSummary()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if transaksi == nil {
+		transaksi = []entity.TransaksiHeader{}
+	}
+	data := gin.H{
+		"transaksi": transaksi,
+		"summary":   summary,
+	}
+
+	common.SendPagedResponseOk(c, data, model.NewPaging(filter.Page.Page, filter.Limit, total), "Succes get all transaksi")
+}
+
+func parseTransaksiFilter(c *gin.Context) (entity.TransaksiFilter, error) {
+	filter := entity.TransaksiFilter{
+		Status: c.Query("status"),
+		SortBy: c.Query("sort"),
+		Desc:   c.Query("order") != "asc",
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseDateRange(c); err != nil {
+		return filter, err
+	}
+	if filter.Page, err = parsePage(c); err != nil {
+		return filter, err
+	}
+	if filter.MinTotal, err = optionalMoney(c, "min_total"); err != nil {
+		return filter, err
+	}
+	if filter.MaxTotal, err = optionalMoney(c, "max_total"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
 }
 
 func (t *TransaksiHandler) GetTransaksiHandler(c *gin.Context) {
@@ -77,73 +152,139 @@ func (t *TransaksiHandler) GetTransaksiHandler(c *gin.Context) {
 
 	header, detail, err := t.TransaksiUsecase.GetTransaksiByID(idTrans)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	payments, err := t.PaymentUsecase.ListByTransaksi(idTrans)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	response := gin.H{
-		"message": "Succes get transaksi by id" + idTrans,
-		"header":  header,
-		"detail":  detail,
+	data := gin.H{
+		"header":   header,
+		"detail":   detail,
+		"payments": payments,
 	}
 
-	c.JSON(http.StatusOK, response)
+	common.SendSingleResponseOk(c, data, "Succes get transaksi by id "+idTrans)
 }
 
 func (t *TransaksiHandler) UpdateTransaksiHandler(c *gin.Context) {
 	idTrans := c.Param("id")
 	var req struct {
 		Header struct {
-			TanggalTransaksi string `json:"tanggal_transaksi"`
+			TanggalTransaksi string      `json:"tanggal_transaksi"`
+			Diskon           money.Money `json:"diskon"`
 		} `json:"header"`
 		Detail []entity.TransaksiDetail `json:"detail"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	tglTrans, err := time.Parse("2006-01-02", req.Header.TanggalTransaksi)
+	tglTrans, err := parseTanggalTransaksi(req.Header.TanggalTransaksi)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		c.Error(err)
 		return
 	}
 
 	header := entity.TransaksiHeader{
 		TglTrans: tglTrans,
+		Diskon:   req.Header.Diskon,
+	}
+
+	updatedHeader, updatedDetail, err := t.TransaksiUsecase.UpdateTransaksiWithDetail(currentUser(c), idTrans, header, req.Detail)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data := gin.H{
+		"header": updatedHeader,
+		"detail": updatedDetail,
+	}
+
+	common.SendSingleResponseOk(c, data, "Transaksi berhasil diperbarui")
+}
+
+func (t *TransaksiHandler) AddPaymentHandler(c *gin.Context) {
+	idTrans := c.Param("id")
+	var req struct {
+		Payments []entity.Payment `json:"payments"`
 	}
 
-	_, _, err = t.TransaksiUsecase.UpdateTransaksiWithDetail(idTrans, header, req.Detail)
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	header, payments, err := t.PaymentUsecase.AddPayments(currentUser(c), idTrans, req.Payments)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transaksi berhasil diperbarui"})
+	common.SendSingleResponseCreated(c, gin.H{"header": header, "payments": payments}, "Pembayaran berhasil dicatat")
 }
 
-func (t *TransaksiHandler) DeleteTransaksiHandler(c *gin.Context) {
+func (t *TransaksiHandler) GetPaymentsHandler(c *gin.Context) {
 	idTrans := c.Param("id")
 
-	err := t.TransaksiUsecase.DeleteTransaksi(idTrans)
+	payments, err := t.PaymentUsecase.ListByTransaksi(idTrans)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(c, payments, "Succes get pembayaran transaksi "+idTrans)
+}
+
+func (t *TransaksiHandler) VoidTransaksiHandler(c *gin.Context) {
+	idTrans := c.Param("id")
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	header, err := t.TransaksiUsecase.VoidTransaksi(currentUser(c), idTrans, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(c, header, "Transaksi berhasil di-void")
+}
+
+// DeleteTransaksiHandler purges a voided transaksi for good, it is for
+// admins only.
+func (t *TransaksiHandler) DeleteTransaksiHandler(c *gin.Context) {
+	idTrans := c.Param("id")
+
+	if err := t.TransaksiUsecase.DeleteTransaksi(currentUser(c), idTrans); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transaksi berhasil dihapus"})
+	common.SendSingleResponseOk(c, nil, "Transaksi berhasil dihapus")
 }
 
 func (t *TransaksiHandler) Route() {
 	t.rg.POST(config.PostTransaksi, t.CreateTransaksiHandler)
 	t.rg.GET(config.GetTransaksiList, t.GetAllTransaksiHandler)
 	t.rg.GET(config.GetTransaksiByID, t.GetTransaksiHandler)
-	t.rg.PUT(config.PutTransaksi, t.UpdateTransaksiHandler)
-	t.rg.DELETE(config.DeleteTransaksi, t.DeleteTransaksiHandler)
+	t.rg.PUT(config.PutTransaksi, RequireRole(entity.RoleSupervisor), t.UpdateTransaksiHandler)
+	t.rg.POST(config.VoidTransaksi, RequireRole(entity.RoleSupervisor), t.VoidTransaksiHandler)
+	t.rg.POST(config.PostPayment, t.AddPaymentHandler)
+	t.rg.GET(config.GetPaymentList, t.GetPaymentsHandler)
+	t.rg.DELETE(config.DeleteTransaksi, RequireRole(entity.RoleAdmin), t.DeleteTransaksiHandler)
 }
 
-func NewTransaksiHandler(transaksiUc usecase.TransaksiUsecase, rg *gin.RouterGroup) *TransaksiHandler {
+func NewTransaksiHandler(transaksiUc usecase.TransaksiUsecase, paymentUc usecase.PaymentUsecase, rg *gin.RouterGroup) *TransaksiHandler {
 	return &TransaksiHandler{
-		TransaksiUsecase: transaksiUc, rg: rg}
+		TransaksiUsecase: transaksiUc, PaymentUsecase: paymentUc, rg: rg}
 }
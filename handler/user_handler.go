@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"roxy/config"
+	"roxy/entity"
+	"roxy/shared/common"
+	"roxy/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	userUc usecase.UserUsecase
+	rg     *gin.RouterGroup
+}
+
+func (u *UserHandler) createHandler(ctx *gin.Context) {
+	var payload entity.User
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	user, err := u.userUc.Create(payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseCreated(ctx, user, "User Created")
+}
+
+func (u *UserHandler) listHandler(ctx *gin.Context) {
+	users, err := u.userUc.List()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, users, "Succes get all user")
+}
+
+func (u *UserHandler) getHandler(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	user, err := u.userUc.GetByID(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, user, "User of Id "+id)
+}
+
+// meHandler returns the user behind the access token.
+func (u *UserHandler) meHandler(ctx *gin.Context) {
+	user, err := u.userUc.GetByID(currentUser(ctx).IDUser)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, user, "Succes get current user")
+}
+
+// updateHandler changes the role, password or aktif of a user. The username
+// stays as it was created.
+func (u *UserHandler) updateHandler(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var payload struct {
+		Role     string `json:"role"`
+		Password string `json:"password"`
+		Aktif    *bool  `json:"aktif"`
+	}
+
+	if !bindJSON(ctx, &payload) {
+		return
+	}
+
+	user, err := u.userUc.Update(id, payload.Role, payload.Password, payload.Aktif)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	common.SendSingleResponseOk(ctx, user, "User of Id "+id+" Updated")
+}
+
+func (u *UserHandler) Route() {
+	u.rg.GET(config.GetMe, u.meHandler)
+
+	admin := RequireRole(entity.RoleAdmin)
+	u.rg.POST(config.PostUser, admin, u.createHandler)
+	u.rg.GET(config.GetUserList, admin, u.listHandler)
+	u.rg.GET(config.GetUser, admin, u.getHandler)
+	u.rg.PUT(config.PutUser, admin, u.updateHandler)
+}
+
+func NewUserHandler(userUc usecase.UserUsecase, rg *gin.RouterGroup) *UserHandler {
+	return &UserHandler{userUc: userUc, rg: rg}
+}
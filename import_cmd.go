@@ -0,0 +1,190 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"roxy/app"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// runImportBarang creates or updates barang from a CSV file laid out like
+// entity.BarangCSVColumns. Only nm_barang is required and columns may come in
+// any order. A row updates the barang with the same sku, or else the same
+// name, and creates one otherwise; empty cells keep what the barang has. Qty
+// is only used for new barang, stock of existing barang changes through stock
+// movements. Bad rows are reported and skipped.
+func runImportBarang(args []string) error {
+	fs := newFlagSet("import-barang")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: roxy import-barang <file.csv>")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(entity.BarangCSVColumns, name) {
+			return fmt.Errorf("unknown column %q, expected some of %s", name, strings.Join(entity.BarangCSVColumns, ", "))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["nm_barang"]; !ok {
+		return errors.New("the nm_barang column is required")
+	}
+
+	a, err := app.New()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	actor := cliActor()
+	var created, updated, failed int
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := r.FieldPos(0)
+		if err != nil {
+			return err
+		}
+
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		isNew, err := importBarang(a, actor, cell)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "line %d: %v\n", line, err)
+			failed++
+		case isNew:
+			created++
+		default:
+			updated++
+		}
+	}
+
+	fmt.Printf("%d created, %d updated, %d failed\n", created, updated, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d rows were not imported", failed)
+	}
+	return nil
+}
+
+// importBarang saves one row and reports whether it created a new barang.
+func importBarang(a *app.App, actor entity.User, cell func(name string) string) (bool, error) {
+	barang, err := findImportedBarang(a, cell("sku"), cell("nm_barang"))
+	if err != nil {
+		return false, err
+	}
+	isNew := barang.Id_barang == ""
+
+	var fields apperror.FieldErrors
+	barang.Nm_barang = cell("nm_barang")
+	if v := cell("sku"); v != "" {
+		barang.Sku = v
+	}
+	if v := cell("kategori"); v != "" {
+		barang.Kategori = v
+	}
+	if v := cell("metode_hpp"); v != "" {
+		barang.MetodeHpp = v
+	}
+	if v := cell("barcodes"); v != "" {
+		barang.Barcodes = strings.Split(v, "|")
+	}
+	for name, dst := range map[string]*money.Money{"harga": &barang.Harga, "harga_pokok": &barang.HargaPokok} {
+		if v := cell(name); v != "" {
+			m, err := money.Parse(v)
+			if err != nil {
+				fields.Add(name, "%s must be an amount", name)
+			}
+			*dst = m
+		}
+	}
+	ints := map[string]*int{
+		"qty":            &barang.Qty,
+		"reorder_point":  &barang.ReorderPoint,
+		"reorder_qty":    &barang.ReorderQty,
+		"lead_time_hari": &barang.LeadTimeHari,
+		"safety_stock":   &barang.SafetyStock,
+	}
+	for name, dst := range ints {
+		if v := cell(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				fields.Add(name, "%s must be a number", name)
+			}
+			*dst = n
+		}
+	}
+	if err := fields.Err(); err != nil {
+		return false, err
+	}
+
+	if isNew {
+		_, err = a.BarangUc.Create(actor, barang)
+	} else {
+		_, err = a.BarangUc.Update(actor, entity.BarangUpdate{
+			Barang:             barang,
+			AllowNegativeStock: &barang.AllowNegativeStock,
+			ReorderPoint:       &barang.ReorderPoint,
+			ReorderQty:         &barang.ReorderQty,
+			LeadTimeHari:       &barang.LeadTimeHari,
+			SafetyStock:        &barang.SafetyStock,
+		})
+	}
+	return isNew, err
+}
+
+// findImportedBarang returns the barang a row refers to, or a zero barang
+// when it is a new one.
+func findImportedBarang(a *app.App, sku, name string) (entity.Barang, error) {
+	if name == "" {
+		return entity.Barang{}, apperror.Invalid("nm_barang", "name cannot be empty")
+	}
+
+	var found entity.Barang
+	var err error
+	if sku != "" {
+		found, err = a.BarangUc.GetBySku(sku)
+	}
+	// both lookups report a missing barang as sql.ErrNoRows
+	if sku == "" || errors.Is(err, sql.ErrNoRows) {
+		found, err = a.BarangUc.GetByName(name)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Barang{}, nil
+	}
+	if err != nil {
+		return entity.Barang{}, err
+	}
+
+	// the lookups above leave out the barcodes, which an update would clear
+	return a.BarangUc.GetByID(found.Id_barang)
+}
@@ -1,9 +1,68 @@
 package main
 
 import (
-	"roxy/handler"
+	"fmt"
+	"os"
+	// the sales report validates timezones with time.LoadLocation, which
+	// must not depend on the tz database of the host
+	_ "time/tzdata"
 )
 
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands is filled in by init, the commands print their own usage from it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "[-migrate]", "run the HTTP server, the default without a command", runServe},
+		{"migrate", "up | down | status | baseline <version>", "apply or roll back schema migrations", runMigrate},
+		{"seed", "", "add sample suppliers and barang for a demo", runSeed},
+		{"import-barang", "<file.csv>", "create or update barang from a CSV file", runImportBarang},
+		{"export", "[-o file] [-from date] [-to date] barang | transaksi", "write barang or transaksi as CSV", runExport},
+		{"create-user", "[-role role] <username>", "create a user, the password is read from stdin", runCreateUser},
+		{"recalc-totals", "[-apply]", "check transaksi totals against their lines and correct them", runRecalcTotals},
+	}
+}
+
 func main() {
-	handler.NewServer().Run()
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(args); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: roxy <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.summary)
+		if cmd.args != "" {
+			fmt.Fprintf(os.Stderr, "  %-14s   roxy %s %s\n", "", cmd.name, cmd.args)
+		}
+	}
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"roxy/app"
+	"roxy/migrations"
+	"roxy/shared/migrate"
+	"strconv"
+)
+
+const migrateUsage = "usage: roxy migrate up | down | status | baseline <version>"
+
+// runMigrate handles roxy migrate up, down, status and baseline.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	a, err := app.New()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	migrator, err := migrate.New(a.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, migration := range done {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("nothing to migrate")
+		}
+		return err
+	case "down":
+		undone, err := migrator.Down(ctx)
+		if undone != nil {
+			fmt.Printf("rolled back %04d_%s\n", undone.Version, undone.Name)
+		} else if err == nil {
+			fmt.Println("nothing to roll back")
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.Modified {
+				state += ", modified since"
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	case "baseline":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		done, err := migrator.Baseline(ctx, version)
+		for _, migration := range done {
+			fmt.Printf("recorded %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,13 @@
+-- Menghapus skema awal, data ikut terhapus
+DROP TABLE IF EXISTS transaksi_detail, transaksi_header, master_barang CASCADE;
+
+DROP SEQUENCE IF EXISTS barang_seq, transaksi_seq, transaksi_detail_seq;
+
+DROP FUNCTION IF EXISTS
+    generate_barang_id(),
+    generate_transaksi_id(),
+    generate_transaksi_detail_id(),
+    update_total_transaksi(),
+    update_total_transaksi_after_update(),
+    update_total_transaksi_after_delete(),
+    update_stok_barang();
@@ -1,5 +1,3 @@
-CREATE DATABASE roxy;
-
 -- CREATE MASTER_BARANG
 CREATE TABLE master_barang (
     id_barang VARCHAR(15) PRIMARY KEY,
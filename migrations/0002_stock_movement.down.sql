@@ -0,0 +1,18 @@
+-- Stok kembali dikurangi oleh trigger, saldo di master_barang.qty tetap
+DROP TABLE IF EXISTS stock_movement;
+
+CREATE OR REPLACE FUNCTION update_stok_barang()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Mengurangi stok barang sesuai dengan jumlah transaksi
+    UPDATE master_barang
+    SET qty = qty - NEW.qty
+    WHERE id_barang = NEW.id_barang;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_update_stok_barang
+AFTER INSERT ON transaksi_detail
+FOR EACH ROW
+EXECUTE FUNCTION update_stok_barang();
@@ -0,0 +1,23 @@
+-- STOCK MOVEMENT LEDGER
+-- master_barang.qty adalah saldo dari stock_movement, stok hanya berubah lewat ledger
+DROP TRIGGER IF EXISTS trg_update_stok_barang ON transaksi_detail;
+DROP FUNCTION IF EXISTS update_stok_barang();
+
+CREATE TABLE stock_movement (
+    id_movement BIGSERIAL PRIMARY KEY,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang) ON DELETE CASCADE,
+    tipe VARCHAR(15) NOT NULL,
+    qty INT NOT NULL,
+    saldo INT NOT NULL,
+    ref_id VARCHAR(15) NOT NULL DEFAULT '',
+    keterangan TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_movement_barang ON stock_movement (id_barang, id_movement);
+
+-- Saldo awal untuk barang yang sudah ada sebelum ledger dipakai
+INSERT INTO stock_movement (id_barang, tipe, qty, saldo, ref_id, keterangan)
+SELECT id_barang, 'adjustment', qty, qty, id_barang, 'saldo awal'
+FROM master_barang
+WHERE qty <> 0;
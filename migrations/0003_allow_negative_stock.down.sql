@@ -0,0 +1 @@
+ALTER TABLE master_barang DROP COLUMN IF EXISTS allow_negative_stock;
@@ -0,0 +1,3 @@
+-- NEGATIVE STOCK POLICY
+-- Default-nya stok tidak boleh minus, bisa diizinkan per barang
+ALTER TABLE master_barang ADD COLUMN allow_negative_stock BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS status;
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS void_reason;
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS voided_at;
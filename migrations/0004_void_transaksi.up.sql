@@ -0,0 +1,5 @@
+-- VOID TRANSAKSI
+-- Transaksi yang di-void tetap disimpan, stoknya dikembalikan lewat ledger
+ALTER TABLE transaksi_header ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'active';
+ALTER TABLE transaksi_header ADD COLUMN void_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE transaksi_header ADD COLUMN voided_at TIMESTAMP;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS retur_detail, retur_header;
+DROP SEQUENCE IF EXISTS retur_seq, retur_detail_seq;
+DROP FUNCTION IF EXISTS generate_retur_id(), generate_retur_detail_id();
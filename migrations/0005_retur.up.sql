@@ -0,0 +1,53 @@
+-- CREATE RETUR HEADER
+CREATE TABLE retur_header (
+    id_retur VARCHAR(15) PRIMARY KEY,
+    id_trans VARCHAR(15) NOT NULL REFERENCES transaksi_header(id_trans) ON DELETE CASCADE,
+    tgl_retur TIMESTAMP NOT NULL,
+    alasan TEXT NOT NULL,
+    total_refund DOUBLE PRECISION NOT NULL
+);
+
+CREATE SEQUENCE retur_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_retur otomatis
+CREATE OR REPLACE FUNCTION generate_retur_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_retur := 'RT-' || LPAD(nextval('retur_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_retur_id
+BEFORE INSERT ON retur_header
+FOR EACH ROW
+WHEN (NEW.id_retur IS NULL)
+EXECUTE FUNCTION generate_retur_id();
+
+-- CREATE RETUR DETAIL
+CREATE TABLE retur_detail (
+    id_retur_detail VARCHAR(15) PRIMARY KEY,
+    id_retur VARCHAR(15) NOT NULL REFERENCES retur_header(id_retur) ON DELETE CASCADE,
+    id_trans_detail VARCHAR(15) NOT NULL REFERENCES transaksi_detail(id_trans_detail) ON DELETE CASCADE,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang) ON DELETE CASCADE,
+    qty INT NOT NULL,
+    harga DOUBLE PRECISION NOT NULL,
+    subtotal DOUBLE PRECISION NOT NULL
+);
+
+CREATE SEQUENCE retur_detail_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_retur_detail otomatis
+CREATE OR REPLACE FUNCTION generate_retur_detail_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_retur_detail := 'RD-' || LPAD(nextval('retur_detail_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_retur_detail_id
+BEFORE INSERT ON retur_detail
+FOR EACH ROW
+WHEN (NEW.id_retur_detail IS NULL)
+EXECUTE FUNCTION generate_retur_detail_id();
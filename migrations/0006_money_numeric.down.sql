@@ -0,0 +1,7 @@
+ALTER TABLE master_barang ALTER COLUMN harga TYPE DOUBLE PRECISION;
+ALTER TABLE transaksi_header ALTER COLUMN total TYPE DOUBLE PRECISION;
+ALTER TABLE transaksi_detail ALTER COLUMN harga TYPE DOUBLE PRECISION;
+ALTER TABLE transaksi_detail ALTER COLUMN subtotal TYPE DOUBLE PRECISION;
+ALTER TABLE retur_header ALTER COLUMN total_refund TYPE DOUBLE PRECISION;
+ALTER TABLE retur_detail ALTER COLUMN harga TYPE DOUBLE PRECISION;
+ALTER TABLE retur_detail ALTER COLUMN subtotal TYPE DOUBLE PRECISION;
@@ -0,0 +1,14 @@
+-- MONEY AS NUMERIC
+-- Nominal rupiah disimpan NUMERIC(15,2) supaya total tidak bergeser karena pembulatan float
+ALTER TABLE master_barang ALTER COLUMN harga TYPE NUMERIC(15,2) USING ROUND(harga::NUMERIC, 2);
+ALTER TABLE transaksi_header ALTER COLUMN total TYPE NUMERIC(15,2) USING ROUND(total::NUMERIC, 2);
+ALTER TABLE transaksi_detail ALTER COLUMN harga TYPE NUMERIC(15,2) USING ROUND(harga::NUMERIC, 2);
+ALTER TABLE transaksi_detail ALTER COLUMN subtotal TYPE NUMERIC(15,2) USING ROUND(subtotal::NUMERIC, 2);
+ALTER TABLE retur_header ALTER COLUMN total_refund TYPE NUMERIC(15,2) USING ROUND(total_refund::NUMERIC, 2);
+ALTER TABLE retur_detail ALTER COLUMN harga TYPE NUMERIC(15,2) USING ROUND(harga::NUMERIC, 2);
+ALTER TABLE retur_detail ALTER COLUMN subtotal TYPE NUMERIC(15,2) USING ROUND(subtotal::NUMERIC, 2);
+
+-- Subtotal baris sudah dibulatkan per baris, total transaksi adalah jumlah subtotal
+UPDATE transaksi_detail SET subtotal = harga * qty;
+UPDATE transaksi_header h
+SET total = COALESCE((SELECT SUM(subtotal) FROM transaksi_detail d WHERE d.id_trans = h.id_trans), 0);
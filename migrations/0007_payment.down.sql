@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS transaksi_payment;
+DROP SEQUENCE IF EXISTS payment_seq;
+DROP FUNCTION IF EXISTS generate_payment_id();
+
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS dibayar;
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS kembalian;
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS status_bayar;
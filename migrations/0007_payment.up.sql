@@ -0,0 +1,33 @@
+-- PEMBAYARAN TRANSAKSI
+ALTER TABLE transaksi_header ADD COLUMN dibayar NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE transaksi_header ADD COLUMN kembalian NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE transaksi_header ADD COLUMN status_bayar VARCHAR(15) NOT NULL DEFAULT 'unpaid';
+
+-- Transaksi lama dianggap sudah lunas di kasir
+UPDATE transaksi_header SET dibayar = total, status_bayar = 'paid';
+
+CREATE TABLE transaksi_payment (
+    id_payment VARCHAR(15) PRIMARY KEY,
+    id_trans VARCHAR(15) NOT NULL REFERENCES transaksi_header(id_trans) ON DELETE CASCADE,
+    metode VARCHAR(15) NOT NULL,
+    jumlah NUMERIC(15,2) NOT NULL,
+    referensi VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE SEQUENCE payment_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_payment otomatis
+CREATE OR REPLACE FUNCTION generate_payment_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_payment := 'PY-' || LPAD(nextval('payment_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_payment_id
+BEFORE INSERT ON transaksi_payment
+FOR EACH ROW
+WHEN (NEW.id_payment IS NULL)
+EXECUTE FUNCTION generate_payment_id();
@@ -0,0 +1,8 @@
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS id_shift;
+ALTER TABLE transaksi_payment DROP COLUMN IF EXISTS id_shift;
+ALTER TABLE transaksi_payment DROP COLUMN IF EXISTS kembalian;
+ALTER TABLE retur_header DROP COLUMN IF EXISTS id_shift;
+
+DROP TABLE IF EXISTS kasir_shift;
+DROP SEQUENCE IF EXISTS shift_seq;
+DROP FUNCTION IF EXISTS generate_shift_id();
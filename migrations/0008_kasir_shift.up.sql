@@ -0,0 +1,36 @@
+-- CREATE KASIR SHIFT
+CREATE TABLE kasir_shift (
+    id_shift VARCHAR(15) PRIMARY KEY,
+    kasir VARCHAR(30) NOT NULL,
+    saldo_awal NUMERIC(15,2) NOT NULL,
+    kas_dihitung NUMERIC(15,2) NOT NULL DEFAULT 0,
+    status VARCHAR(10) NOT NULL,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP
+);
+
+-- Hanya boleh ada satu shift yang terbuka
+CREATE UNIQUE INDEX ux_kasir_shift_open ON kasir_shift (status) WHERE status = 'open';
+
+CREATE SEQUENCE shift_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_shift otomatis
+CREATE OR REPLACE FUNCTION generate_shift_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_shift := 'SH-' || LPAD(nextval('shift_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_shift_id
+BEFORE INSERT ON kasir_shift
+FOR EACH ROW
+WHEN (NEW.id_shift IS NULL)
+EXECUTE FUNCTION generate_shift_id();
+
+-- Transaksi, pembayaran dan retur dicatat pada shift yang sedang terbuka
+ALTER TABLE transaksi_header ADD COLUMN id_shift VARCHAR(15) REFERENCES kasir_shift(id_shift);
+ALTER TABLE transaksi_payment ADD COLUMN id_shift VARCHAR(15) REFERENCES kasir_shift(id_shift);
+ALTER TABLE transaksi_payment ADD COLUMN kembalian NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE retur_header ADD COLUMN id_shift VARCHAR(15) REFERENCES kasir_shift(id_shift);
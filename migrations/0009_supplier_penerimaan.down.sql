@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS penerimaan_detail, penerimaan_header, supplier;
+DROP SEQUENCE IF EXISTS penerimaan_detail_seq, penerimaan_seq, supplier_seq;
+DROP FUNCTION IF EXISTS generate_penerimaan_detail_id(), generate_penerimaan_id(), generate_supplier_id();
@@ -0,0 +1,79 @@
+-- CREATE SUPPLIER
+CREATE TABLE supplier (
+    id_supplier VARCHAR(15) PRIMARY KEY,
+    nm_supplier VARCHAR(50) NOT NULL,
+    alamat TEXT NOT NULL DEFAULT '',
+    telepon VARCHAR(20) NOT NULL DEFAULT ''
+);
+
+CREATE SEQUENCE supplier_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_supplier otomatis
+CREATE OR REPLACE FUNCTION generate_supplier_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_supplier := 'SP-' || LPAD(nextval('supplier_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_supplier_id
+BEFORE INSERT ON supplier
+FOR EACH ROW
+WHEN (NEW.id_supplier IS NULL)
+EXECUTE FUNCTION generate_supplier_id();
+
+-- CREATE PENERIMAAN HEADER
+CREATE TABLE penerimaan_header (
+    id_penerimaan VARCHAR(15) PRIMARY KEY,
+    id_supplier VARCHAR(15) NOT NULL REFERENCES supplier(id_supplier),
+    tgl_penerimaan TIMESTAMP NOT NULL,
+    no_faktur VARCHAR(30) NOT NULL DEFAULT '',
+    total NUMERIC(15,2) NOT NULL
+);
+
+CREATE INDEX idx_penerimaan_supplier_tgl ON penerimaan_header (id_supplier, tgl_penerimaan);
+
+CREATE SEQUENCE penerimaan_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_penerimaan otomatis
+CREATE OR REPLACE FUNCTION generate_penerimaan_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_penerimaan := 'GR-' || LPAD(nextval('penerimaan_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_penerimaan_id
+BEFORE INSERT ON penerimaan_header
+FOR EACH ROW
+WHEN (NEW.id_penerimaan IS NULL)
+EXECUTE FUNCTION generate_penerimaan_id();
+
+-- CREATE PENERIMAAN DETAIL
+CREATE TABLE penerimaan_detail (
+    id_penerimaan_detail VARCHAR(15) PRIMARY KEY,
+    id_penerimaan VARCHAR(15) NOT NULL REFERENCES penerimaan_header(id_penerimaan) ON DELETE CASCADE,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang) ON DELETE CASCADE,
+    qty INT NOT NULL,
+    harga_beli NUMERIC(15,2) NOT NULL,
+    subtotal NUMERIC(15,2) NOT NULL
+);
+
+CREATE SEQUENCE penerimaan_detail_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_penerimaan_detail otomatis
+CREATE OR REPLACE FUNCTION generate_penerimaan_detail_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_penerimaan_detail := 'GD-' || LPAD(nextval('penerimaan_detail_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_penerimaan_detail_id
+BEFORE INSERT ON penerimaan_detail
+FOR EACH ROW
+WHEN (NEW.id_penerimaan_detail IS NULL)
+EXECUTE FUNCTION generate_penerimaan_detail_id();
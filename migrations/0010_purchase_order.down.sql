@@ -0,0 +1,7 @@
+ALTER TABLE penerimaan_header DROP COLUMN IF EXISTS id_po;
+ALTER TABLE penerimaan_detail DROP COLUMN IF EXISTS id_po_detail;
+ALTER TABLE penerimaan_detail DROP COLUMN IF EXISTS over_receipt;
+
+DROP TABLE IF EXISTS purchase_order_detail, purchase_order;
+DROP SEQUENCE IF EXISTS purchase_order_detail_seq, purchase_order_seq;
+DROP FUNCTION IF EXISTS generate_purchase_order_detail_id(), generate_purchase_order_id();
@@ -0,0 +1,61 @@
+-- CREATE PURCHASE ORDER HEADER
+CREATE TABLE purchase_order (
+    id_po VARCHAR(15) PRIMARY KEY,
+    id_supplier VARCHAR(15) NOT NULL REFERENCES supplier(id_supplier),
+    tgl_po TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    catatan TEXT NOT NULL DEFAULT '',
+    total NUMERIC(15,2) NOT NULL
+);
+
+CREATE INDEX idx_purchase_order_supplier_status ON purchase_order (id_supplier, status);
+
+CREATE SEQUENCE purchase_order_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_po otomatis
+CREATE OR REPLACE FUNCTION generate_purchase_order_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_po := 'PO-' || LPAD(nextval('purchase_order_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_purchase_order_id
+BEFORE INSERT ON purchase_order
+FOR EACH ROW
+WHEN (NEW.id_po IS NULL)
+EXECUTE FUNCTION generate_purchase_order_id();
+
+-- CREATE PURCHASE ORDER DETAIL
+CREATE TABLE purchase_order_detail (
+    id_po_detail VARCHAR(15) PRIMARY KEY,
+    id_po VARCHAR(15) NOT NULL REFERENCES purchase_order(id_po) ON DELETE CASCADE,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang),
+    qty INT NOT NULL,
+    qty_diterima INT NOT NULL DEFAULT 0,
+    harga_beli NUMERIC(15,2) NOT NULL,
+    subtotal NUMERIC(15,2) NOT NULL
+);
+
+CREATE SEQUENCE purchase_order_detail_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_po_detail otomatis
+CREATE OR REPLACE FUNCTION generate_purchase_order_detail_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_po_detail := 'PD-' || LPAD(nextval('purchase_order_detail_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_purchase_order_detail_id
+BEFORE INSERT ON purchase_order_detail
+FOR EACH ROW
+WHEN (NEW.id_po_detail IS NULL)
+EXECUTE FUNCTION generate_purchase_order_detail_id();
+
+-- Penerimaan bisa merujuk ke PO; over_receipt menandai qty di atas pesanan
+ALTER TABLE penerimaan_header ADD COLUMN id_po VARCHAR(15) REFERENCES purchase_order(id_po);
+ALTER TABLE penerimaan_detail ADD COLUMN id_po_detail VARCHAR(15) REFERENCES purchase_order_detail(id_po_detail);
+ALTER TABLE penerimaan_detail ADD COLUMN over_receipt BOOLEAN NOT NULL DEFAULT FALSE;
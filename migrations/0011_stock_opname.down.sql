@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_opname_detail, stock_opname;
+DROP SEQUENCE IF EXISTS stock_opname_detail_seq, stock_opname_seq;
+DROP FUNCTION IF EXISTS generate_stock_opname_detail_id(), generate_stock_opname_id();
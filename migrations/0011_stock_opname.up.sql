@@ -0,0 +1,55 @@
+-- CREATE STOCK OPNAME
+-- qty_sistem dan harga adalah snapshot saat opname dibuka, selisih diposting sebagai adjustment saat disetujui
+CREATE TABLE stock_opname (
+    id_opname VARCHAR(15) PRIMARY KEY,
+    tgl_opname TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    catatan TEXT NOT NULL DEFAULT '',
+    kode_alasan VARCHAR(20) NOT NULL DEFAULT '',
+    approved_at TIMESTAMP
+);
+
+CREATE SEQUENCE stock_opname_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_opname otomatis
+CREATE OR REPLACE FUNCTION generate_stock_opname_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_opname := 'SO-' || LPAD(nextval('stock_opname_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_stock_opname_id
+BEFORE INSERT ON stock_opname
+FOR EACH ROW
+WHEN (NEW.id_opname IS NULL)
+EXECUTE FUNCTION generate_stock_opname_id();
+
+-- CREATE STOCK OPNAME DETAIL
+CREATE TABLE stock_opname_detail (
+    id_opname_detail VARCHAR(15) PRIMARY KEY,
+    id_opname VARCHAR(15) NOT NULL REFERENCES stock_opname(id_opname) ON DELETE CASCADE,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang),
+    qty_sistem INT NOT NULL,
+    qty_hitung INT,
+    harga NUMERIC(15,2) NOT NULL,
+    UNIQUE (id_opname, id_barang)
+);
+
+CREATE SEQUENCE stock_opname_detail_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_opname_detail otomatis
+CREATE OR REPLACE FUNCTION generate_stock_opname_detail_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_opname_detail := 'SD-' || LPAD(nextval('stock_opname_detail_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_stock_opname_detail_id
+BEFORE INSERT ON stock_opname_detail
+FOR EACH ROW
+WHEN (NEW.id_opname_detail IS NULL)
+EXECUTE FUNCTION generate_stock_opname_detail_id();
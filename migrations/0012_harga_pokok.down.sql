@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS cost_layer;
+
+ALTER TABLE retur_detail DROP COLUMN IF EXISTS hpp;
+ALTER TABLE transaksi_detail DROP COLUMN IF EXISTS hpp;
+
+DROP INDEX IF EXISTS idx_stock_movement_created;
+ALTER TABLE stock_movement DROP COLUMN IF EXISTS harga_pokok;
+ALTER TABLE stock_movement DROP COLUMN IF EXISTS nilai;
+ALTER TABLE stock_movement DROP COLUMN IF EXISTS nilai_saldo;
+
+ALTER TABLE master_barang DROP COLUMN IF EXISTS harga_pokok;
+ALTER TABLE master_barang DROP COLUMN IF EXISTS metode_hpp;
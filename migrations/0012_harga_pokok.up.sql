@@ -0,0 +1,33 @@
+-- HARGA POKOK (COSTING)
+-- harga_pokok adalah rata-rata bergerak, metode_hpp menentukan HPP penjualan: average atau fifo
+ALTER TABLE master_barang ADD COLUMN harga_pokok NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE master_barang ADD COLUMN metode_hpp VARCHAR(10) NOT NULL DEFAULT 'average';
+
+ALTER TABLE stock_movement ADD COLUMN harga_pokok NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE stock_movement ADD COLUMN nilai NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE stock_movement ADD COLUMN nilai_saldo NUMERIC(15,2) NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_stock_movement_created ON stock_movement (created_at);
+
+-- HPP dicatat per baris saat penjualan dan retur
+ALTER TABLE transaksi_detail ADD COLUMN hpp NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE retur_detail ADD COLUMN hpp NUMERIC(15,2) NOT NULL DEFAULT 0;
+
+-- stock_opname_detail.harga berisi snapshot harga pokok
+
+-- Layer biaya FIFO, qty_sisa berkurang dari layer paling lama
+CREATE TABLE cost_layer (
+    id_layer BIGSERIAL PRIMARY KEY,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang) ON DELETE CASCADE,
+    id_movement BIGINT REFERENCES stock_movement(id_movement) ON DELETE SET NULL,
+    qty_sisa INT NOT NULL,
+    harga_pokok NUMERIC(15,2) NOT NULL
+);
+
+CREATE INDEX idx_cost_layer_barang ON cost_layer (id_barang, id_layer) WHERE qty_sisa > 0;
+
+-- Stok yang sudah ada menjadi layer pertama dengan harga pokok 0
+INSERT INTO cost_layer (id_barang, qty_sisa, harga_pokok)
+SELECT id_barang, qty, harga_pokok
+FROM master_barang
+WHERE qty > 0;
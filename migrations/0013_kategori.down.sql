@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transaksi_header_tgl;
+ALTER TABLE master_barang DROP COLUMN IF EXISTS kategori;
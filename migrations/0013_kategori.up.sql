@@ -0,0 +1,4 @@
+-- KATEGORI BARANG
+ALTER TABLE master_barang ADD COLUMN kategori VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_transaksi_header_tgl ON transaksi_header (tgl_trans);
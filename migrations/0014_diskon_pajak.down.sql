@@ -0,0 +1,48 @@
+-- Total kembali dihitung trigger dari jumlah subtotal
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS diskon;
+ALTER TABLE transaksi_header DROP COLUMN IF EXISTS pajak;
+
+CREATE OR REPLACE FUNCTION update_total_transaksi()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE transaksi_header
+    SET total = (SELECT SUM(subtotal) FROM transaksi_detail WHERE id_trans = NEW.id_trans)
+    WHERE id_trans = NEW.id_trans;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_total_transaksi_after_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE transaksi_header
+    SET total = (SELECT SUM(subtotal) FROM transaksi_detail WHERE id_trans = NEW.id_trans)
+    WHERE id_trans = NEW.id_trans;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_total_transaksi_after_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE transaksi_header
+    SET total = (SELECT SUM(subtotal) FROM transaksi_detail WHERE id_trans = OLD.id_trans)
+    WHERE id_trans = OLD.id_trans;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_update_total_transaksi_insert
+AFTER INSERT ON transaksi_detail
+FOR EACH ROW
+EXECUTE FUNCTION update_total_transaksi();
+
+CREATE TRIGGER trg_update_total_transaksi_update
+AFTER UPDATE ON transaksi_detail
+FOR EACH ROW
+EXECUTE FUNCTION update_total_transaksi_after_update();
+
+CREATE TRIGGER trg_update_total_transaksi_delete
+AFTER DELETE ON transaksi_detail
+FOR EACH ROW
+EXECUTE FUNCTION update_total_transaksi_after_delete();
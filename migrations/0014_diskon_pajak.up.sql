@@ -0,0 +1,11 @@
+-- DISKON DAN PAJAK
+-- total = jumlah subtotal - diskon + pajak, dihitung aplikasi sehingga trigger total tidak dipakai lagi
+ALTER TABLE transaksi_header ADD COLUMN diskon NUMERIC(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE transaksi_header ADD COLUMN pajak NUMERIC(15,2) NOT NULL DEFAULT 0;
+
+DROP TRIGGER IF EXISTS trg_update_total_transaksi_insert ON transaksi_detail;
+DROP TRIGGER IF EXISTS trg_update_total_transaksi_update ON transaksi_detail;
+DROP TRIGGER IF EXISTS trg_update_total_transaksi_delete ON transaksi_detail;
+DROP FUNCTION IF EXISTS update_total_transaksi();
+DROP FUNCTION IF EXISTS update_total_transaksi_after_update();
+DROP FUNCTION IF EXISTS update_total_transaksi_after_delete();
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_transaksi_detail_barang;
@@ -0,0 +1,2 @@
+-- ANALITIK BARANG
+CREATE INDEX idx_transaksi_detail_barang ON transaksi_detail (id_barang);
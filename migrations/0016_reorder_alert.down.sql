@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_alert;
+ALTER TABLE master_barang DROP COLUMN IF EXISTS reorder_point;
+ALTER TABLE master_barang DROP COLUMN IF EXISTS reorder_qty;
@@ -0,0 +1,19 @@
+-- REORDER POINT DAN STOCK ALERT
+-- reorder_point 0 berarti barang tidak dipantau
+ALTER TABLE master_barang ADD COLUMN reorder_point INT NOT NULL DEFAULT 0;
+ALTER TABLE master_barang ADD COLUMN reorder_qty INT NOT NULL DEFAULT 0;
+
+-- Outbox alert, ditulis di transaksi yang sama dengan stock_movement dan dikirim oleh dispatcher
+CREATE TABLE stock_alert (
+    id_alert BIGSERIAL PRIMARY KEY,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang) ON DELETE CASCADE,
+    id_movement BIGINT NOT NULL REFERENCES stock_movement(id_movement) ON DELETE CASCADE,
+    qty INT NOT NULL,
+    reorder_point INT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX idx_stock_alert_pending ON stock_alert (id_alert) WHERE sent_at IS NULL;
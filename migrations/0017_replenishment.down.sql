@@ -0,0 +1,2 @@
+ALTER TABLE master_barang DROP COLUMN IF EXISTS lead_time_hari;
+ALTER TABLE master_barang DROP COLUMN IF EXISTS safety_stock;
@@ -0,0 +1,4 @@
+-- REPLENISHMENT
+-- lead time dalam hari dan stok pengaman per barang untuk usulan pembelian
+ALTER TABLE master_barang ADD COLUMN lead_time_hari INT NOT NULL DEFAULT 0;
+ALTER TABLE master_barang ADD COLUMN safety_stock INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Extension pg_trgm dibiarkan, bisa saja dipakai skema lain di database yang sama
+DROP INDEX IF EXISTS idx_master_barang_nm_trgm;
+DROP INDEX IF EXISTS idx_master_barang_id_trgm;
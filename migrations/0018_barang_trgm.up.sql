@@ -0,0 +1,13 @@
+-- PENCARIAN BARANG
+-- pg_trgm untuk pencarian fuzzy nama dan id barang. Extension ini tidak wajib, bila tidak tersedia
+-- atau user database tidak boleh memasangnya, migrasi tetap jalan dan pencarian memakai LIKE
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+    CREATE INDEX idx_master_barang_nm_trgm ON master_barang USING GIN (nm_barang gin_trgm_ops);
+    CREATE INDEX idx_master_barang_id_trgm ON master_barang USING GIN (id_barang gin_trgm_ops);
+EXCEPTION
+    WHEN undefined_file OR insufficient_privilege OR feature_not_supported THEN
+        RAISE NOTICE 'pg_trgm tidak tersedia, pencarian barang memakai LIKE: %', SQLERRM;
+END
+$$;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS barang_barcode;
+DROP INDEX IF EXISTS idx_master_barang_sku;
+ALTER TABLE master_barang DROP COLUMN IF EXISTS sku;
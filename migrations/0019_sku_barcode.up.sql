@@ -0,0 +1,13 @@
+-- SKU DAN BARCODE
+-- sku kosong berarti barang belum diberi sku, sku yang terisi harus unik
+ALTER TABLE master_barang ADD COLUMN sku VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX idx_master_barang_sku ON master_barang (sku) WHERE sku <> '';
+
+-- Satu barang boleh punya beberapa barcode, UPC-A disimpan sebagai EAN-13 dengan awalan 0
+CREATE TABLE barang_barcode (
+    barcode VARCHAR(20) PRIMARY KEY,
+    id_barang VARCHAR(15) NOT NULL REFERENCES master_barang(id_barang) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_barang_barcode_barang ON barang_barcode (id_barang);
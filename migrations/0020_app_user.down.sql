@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS refresh_token, app_user;
+DROP SEQUENCE IF EXISTS user_seq;
+DROP FUNCTION IF EXISTS generate_user_id();
@@ -0,0 +1,38 @@
+-- USER DAN LOGIN
+-- role: kasir, supervisor atau admin, password disimpan sebagai hash bcrypt
+CREATE TABLE app_user (
+    id_user VARCHAR(15) PRIMARY KEY,
+    username VARCHAR(30) NOT NULL UNIQUE,
+    password_hash VARCHAR(72) NOT NULL,
+    role VARCHAR(15) NOT NULL,
+    aktif BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE SEQUENCE user_seq START 1 INCREMENT 1;
+
+-- Fungsi untuk generate id_user otomatis
+CREATE OR REPLACE FUNCTION generate_user_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.id_user := 'US-' || LPAD(nextval('user_seq')::TEXT, 4, '0');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_generate_user_id
+BEFORE INSERT ON app_user
+FOR EACH ROW
+WHEN (NEW.id_user IS NULL)
+EXECUTE FUNCTION generate_user_id();
+
+-- Refresh token hanya disimpan hash sha256-nya dan diganti setiap kali dipakai
+CREATE TABLE refresh_token (
+    token_hash CHAR(64) PRIMARY KEY,
+    id_user VARCHAR(15) NOT NULL REFERENCES app_user(id_user) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_token_user ON refresh_token (id_user) WHERE revoked_at IS NULL;
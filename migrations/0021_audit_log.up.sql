@@ -0,0 +1,16 @@
+-- AUDIT LOG
+-- Setiap create, update, void dan delete barang dan transaksi, ditulis di transaksi DB yang sama dengan perubahannya
+CREATE TABLE audit_log (
+    id_audit BIGSERIAL PRIMARY KEY,
+    id_user VARCHAR(15) NOT NULL DEFAULT '',
+    username VARCHAR(30) NOT NULL DEFAULT '',
+    action VARCHAR(10) NOT NULL,
+    entity VARCHAR(20) NOT NULL,
+    entity_id VARCHAR(15) NOT NULL,
+    before_data JSONB,
+    after_data JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log (entity, entity_id, id_audit);
+CREATE INDEX idx_audit_log_user ON audit_log (id_user, id_audit);
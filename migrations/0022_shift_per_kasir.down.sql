@@ -0,0 +1,4 @@
+-- Gagal selama masih ada lebih dari satu shift yang terbuka
+DROP INDEX IF EXISTS ux_kasir_shift_open;
+
+CREATE UNIQUE INDEX ux_kasir_shift_open ON kasir_shift (status) WHERE status = 'open';
@@ -0,0 +1,5 @@
+-- SHIFT PER KASIR
+-- Setiap kasir memegang laci sendiri, jadi satu shift terbuka per kasir
+DROP INDEX IF EXISTS ux_kasir_shift_open;
+
+CREATE UNIQUE INDEX ux_kasir_shift_open ON kasir_shift (kasir) WHERE status = 'open';
@@ -0,0 +1,15 @@
+// Package migrations embeds the schema of the database as numbered
+// migrations, NNNN_name.up.sql with an optional NNNN_name.down.sql. A
+// migration that has been applied must not be edited, changes go into a new
+// one. The database itself is created beforehand with CREATE DATABASE.
+//
+// 0001 is the schema of the original DDL.sql and every later change has its
+// own migration. A database set up from a DDL.sql is adopted with roxy
+// migrate baseline <version>, using the last migration that DDL.sql already
+// contained, and then brought up to date with roxy migrate up.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"roxy/app"
+)
+
+// runRecalcTotals recomputes the total of every active transaksi from its
+// lines and lists those that do not add up. Nothing is changed without
+// -apply.
+func runRecalcTotals(args []string) error {
+	fs := newFlagSet("recalc-totals")
+	apply := fs.Bool("apply", false, "save the corrected totals instead of only listing them")
+	fs.Parse(args)
+
+	a, err := app.New()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	corrections, err := a.TransaksiUc.RecalcTotals(cliActor(), *apply)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range corrections {
+		fmt.Printf("%s  total %s -> %s  status_bayar %s -> %s\n", c.IDTrans, c.TotalLama, c.TotalBaru, c.StatusBayarLama, c.StatusBayarBaru)
+	}
+	switch {
+	case len(corrections) == 0:
+		fmt.Println("all totals add up")
+	case *apply:
+		fmt.Printf("%d transaksi corrected\n", len(corrections))
+	default:
+		fmt.Printf("%d transaksi would be corrected, run again with -apply to save\n", len(corrections))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"roxy/entity"
+	"strings"
+)
+
+type AuditRepository interface {
+	List(filter entity.AuditFilter) ([]entity.AuditLog, int, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+// List returns one page of the audit log, newest first, along with the
+// number of matching rows across all pages.
+func (a *auditRepository) List(filter entity.AuditFilter) ([]entity.AuditLog, int, error) {
+	logs := []entity.AuditLog{}
+
+	var args []any
+	var conditions []string
+	addCondition := func(format string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	if filter.Entity != "" {
+		addCondition("entity = $%d", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		addCondition("entity_id = $%d", filter.EntityID)
+	}
+	if filter.IDUser != "" {
+		addCondition("id_user = $%d", filter.IDUser)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at < $%d", filter.To)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := a.db.QueryRow(`SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id_audit, id_user, username, action, entity, entity_id, before_data, after_data, created_at
+        FROM audit_log` + where + ` ORDER BY id_audit DESC` +
+		fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := a.db.Query(query, append(args, filter.Limit, filter.Offset())...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log entity.AuditLog
+		var before, after []byte
+		err := rows.Scan(&log.IDAudit, &log.IDUser, &log.Username, &log.Action, &log.Entity, &log.EntityID, &before, &after, &log.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		// a NULL stays nil, which encodes as null
+		log.Before = before
+		log.After = after
+		logs = append(logs, log)
+	}
+
+	return logs, total, rows.Err()
+}
+
+// writeAudit records a change inside the DB transaction that makes it, so
+// the log never disagrees with the data. A nil before or after is stored as
+// NULL.
+func writeAudit(tx *sql.Tx, actor entity.User, action, auditEntity, entityID string, before, after any) error {
+	beforeData, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_log (id_user, username, action, entity, entity_id, before_data, after_data)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = tx.Exec(query, actor.IDUser, actor.Username, action, auditEntity, entityID, beforeData, afterData)
+	return err
+}
+
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
@@ -2,55 +2,279 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"roxy/entity"
+	"roxy/shared/apperror"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
 )
 
 type MstBarangRepository interface {
-	Create(barang entity.Barang) (entity.Barang, error)
-	List() ([]entity.Barang, error)
+	Create(actor entity.User, barang entity.Barang) (entity.Barang, error)
+	List(filter entity.BarangFilter) ([]entity.Barang, int, error)
 	GetByID(id string) (entity.Barang, error)
 	GetByName(name string) (entity.Barang, error)
-	Update(barang entity.Barang) (entity.Barang, error)
-	Delete(id string) error
+	GetBySku(sku string) (entity.Barang, error)
+	GetByBarcode(code string) (entity.Barang, error)
+	ListLowStock() ([]entity.Barang, error)
+	Search(keyword string, limit int) ([]entity.BarangSearchResult, error)
+	Update(actor entity.User, barang entity.Barang) (entity.Barang, error)
+	Delete(actor entity.User, id string) error
 }
 
 type mstBarangRepository struct {
 	db *sql.DB
 }
 
-func (b *mstBarangRepository) Create(barang entity.Barang) (entity.Barang, error) {
+const barangColumns = `id_barang, nm_barang, sku, kategori, qty, harga, allow_negative_stock, harga_pokok, metode_hpp, reorder_point, reorder_qty, lead_time_hari, safety_stock`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	err := b.db.QueryRow(`INSERT INTO master_barang (nm_barang, qty, harga) VALUES ($1, $2, $3) RETURNING id_barang`, barang.Nm_barang, barang.Qty, barang.Harga).Scan(&barang.Id_barang)
+func scanBarang(row rowScanner) (entity.Barang, error) {
+	var barang entity.Barang
+	err := row.Scan(&barang.Id_barang, &barang.Nm_barang, &barang.Sku, &barang.Kategori, &barang.Qty, &barang.Harga, &barang.AllowNegativeStock, &barang.HargaPokok, &barang.MetodeHpp, &barang.ReorderPoint, &barang.ReorderQty, &barang.LeadTimeHari, &barang.SafetyStock)
+	return barang, err
+}
 
+func (b *mstBarangRepository) Create(actor entity.User, barang entity.Barang) (entity.Barang, error) {
+	tx, err := b.db.Begin()
 	if err != nil {
 		return entity.Barang{}, err
 	}
-	return barang, nil
+	defer tx.Rollback()
+
+	query := `INSERT INTO master_barang (nm_barang, sku, kategori, qty, harga, allow_negative_stock, harga_pokok, metode_hpp, reorder_point, reorder_qty, lead_time_hari, safety_stock)
+        VALUES ($1, $2, $3, 0, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id_barang`
+	err = tx.QueryRow(query, barang.Nm_barang, barang.Sku, barang.Kategori, barang.Harga, barang.AllowNegativeStock, barang.HargaPokok, barang.MetodeHpp,
+		barang.ReorderPoint, barang.ReorderQty, barang.LeadTimeHari, barang.SafetyStock).Scan(&barang.Id_barang)
+	if err != nil {
+		return entity.Barang{}, uniqueViolation(err)
+	}
+
+	if err := replaceBarcodes(tx, barang.Id_barang, barang.Barcodes); err != nil {
+		return entity.Barang{}, err
+	}
+
+	if barang.Qty != 0 {
+		_, err = applyStockMovement(tx, entity.StockMovement{
+			IDBarang:   barang.Id_barang,
+			Tipe:       entity.MovementAdjustment,
+			Qty:        barang.Qty,
+			HargaPokok: barang.HargaPokok,
+			RefID:      barang.Id_barang,
+			Keterangan: "stok awal",
+		})
+		if err != nil {
+			return entity.Barang{}, err
+		}
+	}
+
+	created, err := getBarangTx(tx, barang.Id_barang, false)
+	if err != nil {
+		return entity.Barang{}, err
+	}
+	if err := writeAudit(tx, actor, entity.AuditCreate, entity.AuditBarang, created.Id_barang, nil, created); err != nil {
+		return entity.Barang{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Barang{}, err
+	}
+	return created, nil
 }
 
-func (b *mstBarangRepository) List() ([]entity.Barang, error) {
+// likeEscaper makes user input match literally inside a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List returns one page of the barang matching the filter along with the
+// number of matching rows across all pages.
+func (b *mstBarangRepository) List(filter entity.BarangFilter) ([]entity.Barang, int, error) {
 	var barangs []entity.Barang
 
-	rows, err := b.db.Query(`SELECT id_barang, nm_barang, qty, harga FROM master_barang`)
+	if !slices.Contains(entity.BarangSortColumns, filter.SortBy) {
+		return nil, 0, fmt.Errorf("unknown sort column %q", filter.SortBy)
+	}
+
+	var args []any
+	var conditions []string
+	addCondition := func(format string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	if filter.Nama != "" {
+		addCondition("nm_barang ILIKE '%%' || $%d || '%%'", likeEscaper.Replace(filter.Nama))
+	}
+	if filter.Kategori != "" {
+		addCondition("kategori = $%d", filter.Kategori)
+	}
+	if filter.MinHarga != nil {
+		addCondition("harga >= $%d", *filter.MinHarga)
+	}
+	if filter.MaxHarga != nil {
+		addCondition("harga <= $%d", *filter.MaxHarga)
+	}
+	if filter.MinQty != nil {
+		addCondition("qty >= $%d", *filter.MinQty)
+	}
+	if filter.MaxQty != nil {
+		addCondition("qty <= $%d", *filter.MaxQty)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := b.db.QueryRow(`SELECT COUNT(*) FROM master_barang`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+	query := `SELECT ` + barangColumns + ` FROM master_barang` + where +
+		` ORDER BY ` + filter.SortBy + ` ` + direction + `, id_barang` +
+		fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := b.db.Query(query, append(args, filter.Limit, filter.Offset())...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		barang, err := scanBarang(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		barangs = append(barangs, barang)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	page := make([]*entity.Barang, len(barangs))
+	for i := range barangs {
+		page[i] = &barangs[i]
+	}
+	if err := attachBarcodes(b.db, page); err != nil {
+		return nil, 0, err
+	}
+	return barangs, total, nil
+}
+
+// ListLowStock lists barang whose qty is below their reorder point, the
+// furthest below first. A reorder point of 0 means the barang is not tracked.
+func (b *mstBarangRepository) ListLowStock() ([]entity.Barang, error) {
+	var barangs []entity.Barang
+
+	rows, err := b.db.Query(`SELECT ` + barangColumns + ` FROM master_barang
+        WHERE reorder_point > 0 AND qty < reorder_point ORDER BY reorder_point - qty DESC, id_barang`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var barang entity.Barang
-		err := rows.Scan(&barang.Id_barang, &barang.Nm_barang, &barang.Qty, &barang.Harga)
+		barang, err := scanBarang(rows)
 		if err != nil {
 			return nil, err
 		}
 		barangs = append(barangs, barang)
 	}
-	return barangs, nil
+	return barangs, rows.Err()
 }
 
-func (b *mstBarangRepository) GetByName(name string) (entity.Barang, error) {
-	var barang entity.Barang
+// searchTrigramQuery ranks by pg_trgm similarity against nm_barang and
+// id_barang, so typos still match. Prefix matches, on the name, on any word
+// of it or on the id, are ranked above fuzzy ones for search-as-you-type.
+const searchTrigramQuery = `
+    SELECT ` + barangColumns + `, skor FROM (
+        SELECT *,
+            GREATEST(similarity(nm_barang, $1::text), word_similarity($1::text, nm_barang), similarity(id_barang, $1::text))
+            + CASE WHEN id_barang ILIKE $2::text || '%' OR nm_barang ILIKE $2::text || '%' OR nm_barang ILIKE '% ' || $2::text || '%' THEN 1 ELSE 0 END AS skor
+        FROM master_barang
+        WHERE nm_barang % $1::text OR $1::text <% nm_barang OR id_barang % $1::text
+            OR nm_barang ILIKE '%' || $2::text || '%' OR id_barang ILIKE $2::text || '%'
+    ) b
+    ORDER BY skor DESC, nm_barang, id_barang
+    LIMIT $3::int
+`
+
+// searchLikeQuery is the fallback for Postgres databases where pg_trgm could
+// not be installed, migration 0018 carries on without it. It only finds
+// substrings but ranks them the same way: exact id, then prefixes, then
+// anything containing the keyword, shorter names first. The keyword comes in
+// lower case with its LIKE patterns and its length.
+const searchLikeQuery = `
+    SELECT ` + barangColumns + `, skor FROM (
+        SELECT *,
+            CASE
+                WHEN LOWER(id_barang) = $1 OR LOWER(nm_barang) = $1 THEN 2
+                WHEN LOWER(id_barang) LIKE $2 ESCAPE '\' OR LOWER(nm_barang) LIKE $2 ESCAPE '\' THEN 1.5
+                WHEN LOWER(nm_barang) LIKE $3 ESCAPE '\' THEN 1.25
+                ELSE 1
+            END * CAST($4 AS FLOAT) / CASE WHEN LENGTH(nm_barang) > $4 THEN LENGTH(nm_barang) ELSE $4 END AS skor
+        FROM master_barang
+        WHERE LOWER(nm_barang) LIKE $5 ESCAPE '\' OR LOWER(id_barang) LIKE $2 ESCAPE '\'
+    ) b
+    ORDER BY skor DESC, nm_barang, id_barang
+    LIMIT $6
+`
+
+// pgUndefinedFunction is what Postgres returns for the similarity and %
+// operators of searchTrigramQuery when pg_trgm is not installed.
+const pgUndefinedFunction = "42883"
+
+// Search ranks barang against the keyword by name and id_barang, using
+// pg_trgm when the database has it and plain substring matching otherwise.
+func (b *mstBarangRepository) Search(keyword string, limit int) ([]entity.BarangSearchResult, error) {
+	escaped := likeEscaper.Replace(keyword)
+
+	rows, err := b.db.Query(searchTrigramQuery, keyword, escaped, limit)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pgUndefinedFunction {
+		lower := strings.ToLower(keyword)
+		escaped = likeEscaper.Replace(lower)
+		rows, err = b.db.Query(searchLikeQuery, lower, escaped+"%", "% "+escaped+"%", utf8.RuneCountInString(keyword), "%"+escaped+"%", limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []entity.BarangSearchResult
+	for rows.Next() {
+		var result entity.BarangSearchResult
+		err := rows.Scan(&result.Id_barang, &result.Nm_barang, &result.Sku, &result.Kategori, &result.Qty, &result.Harga, &result.AllowNegativeStock,
+			&result.HargaPokok, &result.MetodeHpp, &result.ReorderPoint, &result.ReorderQty, &result.LeadTimeHari, &result.SafetyStock, &result.Skor)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	matches := make([]*entity.Barang, len(results))
+	for i := range results {
+		matches[i] = &results[i].Barang
+	}
+	if err := attachBarcodes(b.db, matches); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
 
-	err := b.db.QueryRow(`SELECT id_barang, nm_barang, qty, harga FROM master_barang WHERE nm_barang = $1`, name).Scan(&barang.Id_barang, &barang.Nm_barang, &barang.Qty, &barang.Harga)
+func (b *mstBarangRepository) GetByName(name string) (entity.Barang, error) {
+	barang, err := scanBarang(b.db.QueryRow(`SELECT `+barangColumns+` FROM master_barang WHERE nm_barang = $1`, name))
 
 	if err != nil {
 		return entity.Barang{}, err
@@ -59,36 +283,235 @@ func (b *mstBarangRepository) GetByName(name string) (entity.Barang, error) {
 }
 
 func (b *mstBarangRepository) GetByID(id string) (entity.Barang, error) {
-	var barang entity.Barang
+	barang, err := scanBarang(b.db.QueryRow(`SELECT `+barangColumns+` FROM master_barang WHERE id_barang = $1`, id))
 
-	err := b.db.QueryRow(`SELECT id_barang, nm_barang, qty, harga FROM master_barang WHERE id_barang = $1`, id).Scan(&barang.Id_barang, &barang.Nm_barang, &barang.Qty, &barang.Harga)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Barang{}, apperror.NotFound("barang with ID %s not found", id)
+		}
+		return entity.Barang{}, err
+	}
 
+	if err := attachBarcodes(b.db, []*entity.Barang{&barang}); err != nil {
+		return entity.Barang{}, err
+	}
+	return barang, nil
+
+}
+
+func (b *mstBarangRepository) GetBySku(sku string) (entity.Barang, error) {
+	barang, err := scanBarang(b.db.QueryRow(`SELECT `+barangColumns+` FROM master_barang WHERE sku = $1`, sku))
+
+	if err != nil {
+		return entity.Barang{}, err
+	}
+	return barang, nil
+}
+
+// GetByBarcode expects the code in its normalized form, see barcode.Normalize.
+func (b *mstBarangRepository) GetByBarcode(code string) (entity.Barang, error) {
+	query := `SELECT ` + barangColumns + ` FROM master_barang
+        WHERE id_barang = (SELECT id_barang FROM barang_barcode WHERE barcode = $1)`
+	barang, err := scanBarang(b.db.QueryRow(query, code))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Barang{}, apperror.NotFound("barcode %s not found", code)
+		}
 		return entity.Barang{}, err
 	}
 
+	if err := attachBarcodes(b.db, []*entity.Barang{&barang}); err != nil {
+		return entity.Barang{}, err
+	}
 	return barang, nil
+}
+
+// replaceBarcodes makes codes the full set of barcodes of the barang.
+func replaceBarcodes(tx *sql.Tx, idBarang string, codes []string) error {
+	if _, err := tx.Exec(`DELETE FROM barang_barcode WHERE id_barang = $1`, idBarang); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		_, err := tx.Exec(`INSERT INTO barang_barcode (barcode, id_barang) VALUES ($1, $2)`, code, idBarang)
+		if err != nil {
+			return uniqueViolation(err)
+		}
+	}
+	return nil
+}
+
+// attachBarcodes fills in the barcodes of the given barang with one query.
+func attachBarcodes(q queryer, barangs []*entity.Barang) error {
+	ids := make([]string, 0, len(barangs))
+	byID := make(map[string]*entity.Barang, len(barangs))
+	for _, barang := range barangs {
+		barang.Barcodes = []string{}
+		ids = append(ids, barang.Id_barang)
+		byID[barang.Id_barang] = barang
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := q.Query(`SELECT id_barang, barcode FROM barang_barcode WHERE id_barang = ANY($1) ORDER BY barcode`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var idBarang, code string
+		if err := rows.Scan(&idBarang, &code); err != nil {
+			return err
+		}
+		byID[idBarang].Barcodes = append(byID[idBarang].Barcodes, code)
+	}
+	return rows.Err()
+}
 
+// pgUniqueViolation is returned when an insert or update hits a unique index.
+const pgUniqueViolation = "23505"
+
+// uniqueViolation turns a clash on the sku or barcode indexes into an error
+// naming the value that is already taken.
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != pgUniqueViolation {
+		return err
+	}
+	switch pqErr.Constraint {
+	case "idx_master_barang_sku":
+		return apperror.Conflict("sku already exist")
+	case "barang_barcode_pkey":
+		return apperror.Conflict("barcode already exist")
+	case "app_user_username_key":
+		return apperror.Conflict("username already exist")
+	}
+	return err
 }
-func (b *mstBarangRepository) Update(barang entity.Barang) (entity.Barang, error) {
 
-	_, err := b.db.Exec(`UPDATE master_barang SET nm_barang = $2, qty = $3, harga = $4 WHERE id_barang = $1`, barang.Id_barang, barang.Nm_barang, barang.Qty, barang.Harga)
+func (b *mstBarangRepository) Update(actor entity.User, barang entity.Barang) (entity.Barang, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return entity.Barang{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := getBarangTx(tx, barang.Id_barang, true)
+	if err != nil {
+		return entity.Barang{}, err
+	}
+	currentQty := before.Qty
+
+	if barang.Qty < 0 && !barang.AllowNegativeStock {
+		return entity.Barang{}, &entity.InsufficientStockError{Items: []entity.InsufficientStock{
+			{IDBarang: barang.Id_barang, Requested: currentQty - barang.Qty, Available: currentQty},
+		}}
+	}
+
+	query := `UPDATE master_barang SET nm_barang = $2, sku = $3, kategori = $4, harga = $5, allow_negative_stock = $6, metode_hpp = $7,
+        reorder_point = $8, reorder_qty = $9, lead_time_hari = $10, safety_stock = $11
+        WHERE id_barang = $1`
+	_, err = tx.Exec(query, barang.Id_barang, barang.Nm_barang, barang.Sku, barang.Kategori, barang.Harga, barang.AllowNegativeStock, barang.MetodeHpp,
+		barang.ReorderPoint, barang.ReorderQty, barang.LeadTimeHari, barang.SafetyStock)
+	if err != nil {
+		return entity.Barang{}, uniqueViolation(err)
+	}
+
+	// nil leaves the barcodes alone, an empty list removes them all
+	if barang.Barcodes != nil {
+		if err := replaceBarcodes(tx, barang.Id_barang, barang.Barcodes); err != nil {
+			return entity.Barang{}, err
+		}
+	}
 
+	// qty is never written directly, a different value is booked as an adjustment
+	if barang.Qty != currentQty {
+		_, err = applyStockMovement(tx, entity.StockMovement{
+			IDBarang:   barang.Id_barang,
+			Tipe:       entity.MovementAdjustment,
+			Qty:        barang.Qty - currentQty,
+			RefID:      barang.Id_barang,
+			Keterangan: "update barang",
+		})
+		if err != nil {
+			return entity.Barang{}, err
+		}
+	}
+
+	// harga_pokok is maintained by the ledger, read back what it ended up as
+	barang, err = getBarangTx(tx, barang.Id_barang, false)
 	if err != nil {
 		return entity.Barang{}, err
 	}
+	if err := writeAudit(tx, actor, entity.AuditUpdate, entity.AuditBarang, barang.Id_barang, before, barang); err != nil {
+		return entity.Barang{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Barang{}, err
+	}
 
 	return barang, nil
 }
-func (b *mstBarangRepository) Delete(id string) error {
 
-	_, err := b.db.Exec(`DELETE FROM master_barang WHERE id_barang = $1`, id)
+func (b *mstBarangRepository) Delete(actor entity.User, id string) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getBarangTx(tx, id, true)
+	if err != nil {
+		return err
+	}
 
+	// deleting would cascade into the ledger, the cost layers and the sold
+	// lines, a barang with history has to stay
+	var used bool
+	queryUsed := `
+        SELECT EXISTS (SELECT 1 FROM stock_movement WHERE id_barang = $1)
+            OR EXISTS (SELECT 1 FROM transaksi_detail WHERE id_barang = $1)
+            OR EXISTS (SELECT 1 FROM penerimaan_detail WHERE id_barang = $1)
+            OR EXISTS (SELECT 1 FROM purchase_order_detail WHERE id_barang = $1)
+            OR EXISTS (SELECT 1 FROM stock_opname_detail WHERE id_barang = $1)
+    `
+	if err := tx.QueryRow(queryUsed, id).Scan(&used); err != nil {
+		return err
+	}
+	if used {
+		return apperror.Conflict("barang %s sudah punya riwayat stok atau penjualan dan tidak bisa dihapus", id)
+	}
+
+	_, err = tx.Exec(`DELETE FROM master_barang WHERE id_barang = $1`, id)
 	if err != nil {
 		return err
 	}
+	if err := writeAudit(tx, actor, entity.AuditDelete, entity.AuditBarang, id, before, nil); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit()
+}
+
+// getBarangTx reads a barang with its barcodes inside tx, locking the row
+// when forUpdate is set.
+func getBarangTx(tx *sql.Tx, id string, forUpdate bool) (entity.Barang, error) {
+	query := `SELECT ` + barangColumns + ` FROM master_barang WHERE id_barang = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+	barang, err := scanBarang(tx.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Barang{}, apperror.NotFound("barang with ID %s not found", id)
+		}
+		return entity.Barang{}, err
+	}
+	if err := attachBarcodes(tx, []*entity.Barang{&barang}); err != nil {
+		return entity.Barang{}, err
+	}
+	return barang, nil
 }
 
 func NewBarangRepository(db *sql.DB) MstBarangRepository {
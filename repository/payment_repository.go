@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"database/sql"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+)
+
+type PaymentRepository interface {
+	AddPayments(actor entity.User, idTrans string, payments []entity.Payment) (entity.TransaksiHeader, []entity.Payment, error)
+	ListByTransaksi(idTrans string) ([]entity.Payment, error)
+}
+
+type paymentRepository struct {
+	db *sql.DB
+}
+
+func (p *paymentRepository) AddPayments(actor entity.User, idTrans string, payments []entity.Payment) (entity.TransaksiHeader, []entity.Payment, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveTransaksi(tx, idTrans); err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+	before, err := transaksiSnapshot(tx, idTrans)
+	if err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+
+	total, dibayar := before.Header.Total, before.Header.Dibayar
+	if dibayar >= total {
+		return entity.TransaksiHeader{}, payments, apperror.Conflict("transaksi %s sudah lunas", idTrans)
+	}
+
+	idShift, err := lockOpenShift(tx, actor.Username)
+	if err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+
+	payments, err = insertPayments(tx, idTrans, idShift, total-dibayar, payments)
+	if err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+
+	after, err := transaksiSnapshot(tx, idTrans)
+	if err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+	if err := writeAudit(tx, actor, entity.AuditUpdate, entity.AuditTransaksi, idTrans, before, after); err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+
+	return after.Header, payments, nil
+}
+
+func (p *paymentRepository) ListByTransaksi(idTrans string) ([]entity.Payment, error) {
+	return listPayments(p.db, idTrans)
+}
+
+func listPayments(q queryer, idTrans string) ([]entity.Payment, error) {
+	var payments []entity.Payment
+
+	query := `SELECT id_payment, id_trans, COALESCE(id_shift, ''), metode, jumlah, kembalian, referensi, created_at FROM transaksi_payment WHERE id_trans = $1 ORDER BY id_payment`
+	rows, err := q.Query(query, idTrans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payment entity.Payment
+		err := rows.Scan(&payment.IDPayment, &payment.IDTrans, &payment.IDShift, &payment.Metode, &payment.Jumlah, &payment.Kembalian, &payment.Referensi, &payment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// insertPayments records the tenders against the amount still due. Only cash
+// may exceed what is due, the excess is the change handed back to the
+// customer; a non-cash tender larger than the due amount is rejected. The
+// change is booked on the cash rows so each shift knows what left its drawer.
+func insertPayments(tx *sql.Tx, idTrans string, idShift string, due money.Money, payments []entity.Payment) ([]entity.Payment, error) {
+	var cash, nonCash money.Money
+	for _, payment := range payments {
+		if payment.Metode == entity.PaymentCash {
+			cash += payment.Jumlah
+		} else {
+			nonCash += payment.Jumlah
+		}
+	}
+	if nonCash > due {
+		return payments, apperror.Conflict("pembayaran non-tunai %s melebihi sisa tagihan %s", nonCash, due)
+	}
+
+	kembalian := allocateChange(payments, due)
+
+	for i := range payments {
+		payments[i].IDTrans = idTrans
+		payments[i].IDShift = idShift
+
+		query := `
+            INSERT INTO transaksi_payment (id_trans, id_shift, metode, jumlah, kembalian, referensi)
+            VALUES ($1, $2, $3, $4, $5, $6) RETURNING id_payment, created_at
+        `
+		err := tx.QueryRow(query, idTrans, idShift, payments[i].Metode, payments[i].Jumlah, payments[i].Kembalian, payments[i].Referensi).Scan(&payments[i].IDPayment, &payments[i].CreatedAt)
+		if err != nil {
+			return payments, err
+		}
+	}
+
+	_, err := tx.Exec(`UPDATE transaksi_header SET dibayar = dibayar + $2, kembalian = kembalian + $3 WHERE id_trans = $1`,
+		idTrans, cash+nonCash-kembalian, kembalian)
+	if err != nil {
+		return payments, err
+	}
+
+	return payments, refreshPaymentStatus(tx, idTrans)
+}
+
+// allocateChange works out the change on tenders that pay due and returns it.
+// Only cash gives change, so the change is what was tendered over due but no
+// more than the cash; it is booked on the cash tenders from the last one
+// back. Any kembalian the tenders had before is replaced.
+func allocateChange(payments []entity.Payment, due money.Money) money.Money {
+	var cash, tendered money.Money
+	for i := range payments {
+		payments[i].Kembalian = 0
+		tendered += payments[i].Jumlah
+		if payments[i].Metode == entity.PaymentCash {
+			cash += payments[i].Jumlah
+		}
+	}
+	kembalian := min(cash, max(tendered-due, 0))
+
+	remainingChange := kembalian
+	for i := len(payments) - 1; i >= 0 && remainingChange > 0; i-- {
+		if payments[i].Metode != entity.PaymentCash {
+			continue
+		}
+		payments[i].Kembalian = min(payments[i].Jumlah, remainingChange)
+		remainingChange -= payments[i].Kembalian
+	}
+	return kembalian
+}
+
+// reconcilePayments settles the payments of a transaksi again after its total
+// changed. The change is worked out anew on all its tenders, so an edit that
+// lowers the total below what was paid in cash hands the difference back as
+// kembalian, and dibayar and status_bayar follow. Non-cash paid over the new
+// total stays in dibayar, it cannot be handed back from the drawer.
+func reconcilePayments(tx *sql.Tx, idTrans string) error {
+	var total money.Money
+	if err := tx.QueryRow(`SELECT total FROM transaksi_header WHERE id_trans = $1`, idTrans).Scan(&total); err != nil {
+		return err
+	}
+	payments, err := listPayments(tx, idTrans)
+	if err != nil {
+		return err
+	}
+
+	booked := make([]money.Money, len(payments))
+	var tendered money.Money
+	for i, payment := range payments {
+		booked[i] = payment.Kembalian
+		tendered += payment.Jumlah
+	}
+	kembalian := allocateChange(payments, total)
+	for i, payment := range payments {
+		if payment.Kembalian == booked[i] {
+			continue
+		}
+		_, err := tx.Exec(`UPDATE transaksi_payment SET kembalian = $2 WHERE id_payment = $1`, payment.IDPayment, payment.Kembalian)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE transaksi_header SET dibayar = $2, kembalian = $3 WHERE id_trans = $1`, idTrans, tendered-kembalian, kembalian)
+	if err != nil {
+		return err
+	}
+	return refreshPaymentStatus(tx, idTrans)
+}
+
+// refreshPaymentStatus derives status_bayar from dibayar and total, it has to
+// run whenever either of them changes.
+func refreshPaymentStatus(tx *sql.Tx, idTrans string) error {
+	query := `
+        UPDATE transaksi_header SET status_bayar = CASE
+            WHEN dibayar >= total THEN $2
+            WHEN dibayar > 0 THEN $3
+            ELSE $4
+        END
+        WHERE id_trans = $1
+    `
+	_, err := tx.Exec(query, idTrans, entity.PaymentPaid, entity.PaymentPartiallyPaid, entity.PaymentUnpaid)
+	return err
+}
+
+func NewPaymentRepository(db *sql.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"roxy/entity"
+	"roxy/shared/money"
+	"slices"
+	"testing"
+)
+
+func TestAllocateChange(t *testing.T) {
+	cash := func(rupiah int64) entity.Payment {
+		return entity.Payment{Metode: entity.PaymentCash, Jumlah: money.New(rupiah)}
+	}
+	card := func(rupiah int64) entity.Payment {
+		return entity.Payment{Metode: entity.PaymentDebitCard, Jumlah: money.New(rupiah)}
+	}
+
+	tests := []struct {
+		name      string
+		payments  []entity.Payment
+		due       money.Money
+		kembalian money.Money
+		perRow    []int64 // kembalian of each row in rupiah
+	}{
+		{"exact cash", []entity.Payment{cash(50000)}, money.New(50000), 0, []int64{0}},
+		{"cash with change", []entity.Payment{cash(100000)}, money.New(73500), money.New(26500), []int64{26500}},
+		{"short", []entity.Payment{cash(20000)}, money.New(50000), 0, []int64{0}},
+		{"card and cash change", []entity.Payment{card(30000), cash(50000)}, money.New(60000), money.New(20000), []int64{0, 20000}},
+		{"change from the last cash first", []entity.Payment{cash(20000), cash(10000)}, money.New(15000), money.New(15000), []int64{5000, 10000}},
+		{"cash before card", []entity.Payment{cash(20000), card(40000)}, money.New(50000), money.New(10000), []int64{10000, 0}},
+		{"card over due gives no change", []entity.Payment{card(80000)}, money.New(60000), 0, []int64{0}},
+		{"change capped at cash", []entity.Payment{card(70000), cash(5000)}, money.New(60000), money.New(5000), []int64{0, 5000}},
+		{"nothing due", []entity.Payment{cash(10000)}, 0, money.New(10000), []int64{10000}},
+		{"no payments", nil, money.New(10000), 0, []int64{}},
+		{
+			// the total of an edited transaksi dropped below what was paid,
+			// the change booked before is replaced
+			name:      "recomputed after an edit",
+			payments:  []entity.Payment{cash(50000), {Metode: entity.PaymentCash, Jumlah: money.New(50000), Kembalian: money.New(10000)}},
+			due:       money.New(70000),
+			kembalian: money.New(30000),
+			perRow:    []int64{0, 30000},
+		},
+		{
+			name:      "total raised after an edit",
+			payments:  []entity.Payment{{Metode: entity.PaymentCash, Jumlah: money.New(100000), Kembalian: money.New(40000)}},
+			due:       money.New(90000),
+			kembalian: money.New(10000),
+			perRow:    []int64{10000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := slices.Clone(tt.payments)
+			if got := allocateChange(payments, tt.due); got != tt.kembalian {
+				t.Errorf("kembalian = %s, want %s", got, tt.kembalian)
+			}
+			perRow := []int64{}
+			for _, payment := range payments {
+				perRow = append(perRow, int64(payment.Kembalian)/100)
+			}
+			if !slices.Equal(perRow, tt.perRow) {
+				t.Errorf("kembalian per row = %v, want %v", perRow, tt.perRow)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"strings"
+)
+
+type PenerimaanRepository interface {
+	CreatePenerimaan(header entity.PenerimaanHeader, details []entity.PenerimaanDetail, poTolerance int) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error)
+	ListPenerimaan(filter entity.PenerimaanFilter) ([]entity.PenerimaanHeader, error)
+	GetPenerimaanByID(idPenerimaan string) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error)
+}
+
+type penerimaanRepository struct {
+	db *sql.DB
+}
+
+// CreatePenerimaan books the received goods into stock. When the receipt
+// refers to a purchase order its lines are checked against the outstanding
+// qty of the purchase order lines, see receivePurchaseOrder.
+func (p *penerimaanRepository) CreatePenerimaan(header entity.PenerimaanHeader, details []entity.PenerimaanDetail, poTolerance int) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return header, details, err
+	}
+	defer tx.Rollback()
+
+	if header.IDPO != "" {
+		if err := receivePurchaseOrder(tx, header.IDPO, details, poTolerance); err != nil {
+			return header, details, err
+		}
+	}
+
+	queryHeader := `
+        INSERT INTO penerimaan_header (id_supplier, id_po, tgl_penerimaan, no_faktur, total)
+        VALUES ($1, NULLIF($2, ''), $3, $4, $5) RETURNING id_penerimaan
+    `
+	err = tx.QueryRow(queryHeader, header.IDSupplier, header.IDPO, header.TglPenerimaan, header.NoFaktur, header.Total).Scan(&header.IDPenerimaan)
+	if err != nil {
+		return header, details, err
+	}
+
+	for i := range details {
+		details[i].IDPenerimaan = header.IDPenerimaan
+
+		queryDetail := `
+            INSERT INTO penerimaan_detail (id_penerimaan, id_po_detail, id_barang, qty, harga_beli, subtotal, over_receipt)
+            VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7) RETURNING id_penerimaan_detail
+        `
+		err = tx.QueryRow(queryDetail, details[i].IDPenerimaan, details[i].IDPODetail, details[i].IDBarang, details[i].Qty, details[i].HargaBeli, details[i].Subtotal, details[i].OverReceipt).Scan(&details[i].IDPenerimaanDetail)
+		if err != nil {
+			return header, details, err
+		}
+
+		_, err = applyStockMovement(tx, entity.StockMovement{
+			IDBarang:   details[i].IDBarang,
+			Tipe:       entity.MovementPurchase,
+			Qty:        details[i].Qty,
+			HargaPokok: details[i].HargaBeli,
+			RefID:      header.IDPenerimaan,
+			Keterangan: header.NoFaktur,
+		})
+		if err != nil {
+			return header, details, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return header, details, err
+	}
+
+	return header, details, nil
+}
+
+func (p *penerimaanRepository) ListPenerimaan(filter entity.PenerimaanFilter) ([]entity.PenerimaanHeader, error) {
+	var penerimaans []entity.PenerimaanHeader
+
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.IDSupplier != "" {
+		args = append(args, filter.IDSupplier)
+		conditions = append(conditions, fmt.Sprintf("id_supplier = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("tgl_penerimaan >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("tgl_penerimaan < $%d", len(args)))
+	}
+
+	query := `SELECT id_penerimaan, id_supplier, COALESCE(id_po, ''), tgl_penerimaan, no_faktur, total FROM penerimaan_header`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY tgl_penerimaan, id_penerimaan`
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var penerimaan entity.PenerimaanHeader
+		err := rows.Scan(&penerimaan.IDPenerimaan, &penerimaan.IDSupplier, &penerimaan.IDPO, &penerimaan.TglPenerimaan, &penerimaan.NoFaktur, &penerimaan.Total)
+		if err != nil {
+			return nil, err
+		}
+		penerimaans = append(penerimaans, penerimaan)
+	}
+
+	return penerimaans, rows.Err()
+}
+
+func (p *penerimaanRepository) GetPenerimaanByID(idPenerimaan string) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error) {
+	var header entity.PenerimaanHeader
+	var details []entity.PenerimaanDetail
+
+	queryHeader := `SELECT id_penerimaan, id_supplier, COALESCE(id_po, ''), tgl_penerimaan, no_faktur, total FROM penerimaan_header WHERE id_penerimaan = $1`
+	err := p.db.QueryRow(queryHeader, idPenerimaan).Scan(&header.IDPenerimaan, &header.IDSupplier, &header.IDPO, &header.TglPenerimaan, &header.NoFaktur, &header.Total)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return header, details, apperror.NotFound("penerimaan not found")
+		}
+		return header, details, err
+	}
+
+	queryDetail := `SELECT id_penerimaan_detail, id_penerimaan, COALESCE(id_po_detail, ''), id_barang, qty, harga_beli, subtotal, over_receipt FROM penerimaan_detail WHERE id_penerimaan = $1 ORDER BY id_penerimaan_detail`
+	rows, err := p.db.Query(queryDetail, idPenerimaan)
+	if err != nil {
+		return header, details, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail entity.PenerimaanDetail
+		err := rows.Scan(&detail.IDPenerimaanDetail, &detail.IDPenerimaan, &detail.IDPODetail, &detail.IDBarang, &detail.Qty, &detail.HargaBeli, &detail.Subtotal, &detail.OverReceipt)
+		if err != nil {
+			return header, details, err
+		}
+		details = append(details, detail)
+	}
+
+	return header, details, rows.Err()
+}
+
+func NewPenerimaanRepository(db *sql.DB) PenerimaanRepository {
+	return &penerimaanRepository{db: db}
+}
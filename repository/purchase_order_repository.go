@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type PurchaseOrderRepository interface {
+	CreatePurchaseOrder(header entity.PurchaseOrderHeader, details []entity.PurchaseOrderDetail) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error)
+	ListPurchaseOrder(filter entity.PurchaseOrderFilter) ([]entity.PurchaseOrderHeader, error)
+	GetPurchaseOrderByID(idPO string) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error)
+	UpdateStatus(idPO string, status string, allowedFrom []string) (entity.PurchaseOrderHeader, error)
+}
+
+type purchaseOrderRepository struct {
+	db *sql.DB
+}
+
+const purchaseOrderColumns = `id_po, id_supplier, tgl_po, status, catatan, total`
+
+func scanPurchaseOrder(row rowScanner) (entity.PurchaseOrderHeader, error) {
+	var header entity.PurchaseOrderHeader
+	err := row.Scan(&header.IDPO, &header.IDSupplier, &header.TglPO, &header.Status, &header.Catatan, &header.Total)
+	return header, err
+}
+
+func (p *purchaseOrderRepository) CreatePurchaseOrder(header entity.PurchaseOrderHeader, details []entity.PurchaseOrderDetail) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return header, details, err
+	}
+	defer tx.Rollback()
+
+	queryHeader := `
+        INSERT INTO purchase_order (id_supplier, tgl_po, status, catatan, total)
+        VALUES ($1, $2, $3, $4, $5) RETURNING id_po
+    `
+	err = tx.QueryRow(queryHeader, header.IDSupplier, header.TglPO, header.Status, header.Catatan, header.Total).Scan(&header.IDPO)
+	if err != nil {
+		return header, details, err
+	}
+
+	for i := range details {
+		details[i].IDPO = header.IDPO
+
+		queryDetail := `
+            INSERT INTO purchase_order_detail (id_po, id_barang, qty, harga_beli, subtotal)
+            VALUES ($1, $2, $3, $4, $5) RETURNING id_po_detail
+        `
+		err = tx.QueryRow(queryDetail, details[i].IDPO, details[i].IDBarang, details[i].Qty, details[i].HargaBeli, details[i].Subtotal).Scan(&details[i].IDPODetail)
+		if err != nil {
+			return header, details, err
+		}
+		details[i].QtyOutstanding = details[i].Qty
+	}
+
+	if err := tx.Commit(); err != nil {
+		return header, details, err
+	}
+
+	return header, details, nil
+}
+
+func (p *purchaseOrderRepository) ListPurchaseOrder(filter entity.PurchaseOrderFilter) ([]entity.PurchaseOrderHeader, error) {
+	var purchaseOrders []entity.PurchaseOrderHeader
+
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.IDSupplier != "" {
+		args = append(args, filter.IDSupplier)
+		conditions = append(conditions, fmt.Sprintf("id_supplier = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := `SELECT ` + purchaseOrderColumns + ` FROM purchase_order`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY tgl_po, id_po`
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		purchaseOrder, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		purchaseOrders = append(purchaseOrders, purchaseOrder)
+	}
+
+	return purchaseOrders, rows.Err()
+}
+
+func (p *purchaseOrderRepository) GetPurchaseOrderByID(idPO string) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error) {
+	header, err := scanPurchaseOrder(p.db.QueryRow(`SELECT `+purchaseOrderColumns+` FROM purchase_order WHERE id_po = $1`, idPO))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return header, nil, apperror.NotFound("purchase order not found")
+		}
+		return header, nil, err
+	}
+
+	details, err := listPurchaseOrderDetail(p.db, idPO)
+	if err != nil {
+		return header, nil, err
+	}
+
+	return header, details, nil
+}
+
+// UpdateStatus moves a purchase order to status, but only when its current
+// status is one of allowedFrom.
+func (p *purchaseOrderRepository) UpdateStatus(idPO string, status string, allowedFrom []string) (entity.PurchaseOrderHeader, error) {
+	query := `UPDATE purchase_order SET status = $2 WHERE id_po = $1 AND status = ANY($3) RETURNING ` + purchaseOrderColumns
+	header, err := scanPurchaseOrder(p.db.QueryRow(query, idPO, status, pq.Array(allowedFrom)))
+	if err == nil {
+		return header, nil
+	}
+	if err != sql.ErrNoRows {
+		return header, err
+	}
+
+	var current string
+	err = p.db.QueryRow(`SELECT status FROM purchase_order WHERE id_po = $1`, idPO).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return header, apperror.NotFound("purchase order not found")
+		}
+		return header, err
+	}
+	return header, apperror.Conflict("purchase order %s berstatus %s dan tidak bisa diubah menjadi %s", idPO, current, status)
+}
+
+func listPurchaseOrderDetail(q queryer, idPO string) ([]entity.PurchaseOrderDetail, error) {
+	var details []entity.PurchaseOrderDetail
+
+	query := `SELECT id_po_detail, id_po, id_barang, qty, qty_diterima, harga_beli, subtotal FROM purchase_order_detail WHERE id_po = $1 ORDER BY id_po_detail`
+	rows, err := q.Query(query, idPO)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail entity.PurchaseOrderDetail
+		err := rows.Scan(&detail.IDPODetail, &detail.IDPO, &detail.IDBarang, &detail.Qty, &detail.QtyDiterima, &detail.HargaBeli, &detail.Subtotal)
+		if err != nil {
+			return nil, err
+		}
+		detail.QtyOutstanding = max(detail.Qty-detail.QtyDiterima, 0)
+		details = append(details, detail)
+	}
+
+	return details, rows.Err()
+}
+
+// receivePurchaseOrder books the received lines on their purchase order lines
+// while holding the purchase order row lock. Receiving more than ordered is
+// allowed up to tolerancePercent and flagged on the receipt line, anything
+// beyond the tolerance is rejected.
+func receivePurchaseOrder(tx *sql.Tx, idPO string, details []entity.PenerimaanDetail, tolerancePercent int) error {
+	var status string
+	err := tx.QueryRow(`SELECT status FROM purchase_order WHERE id_po = $1 FOR UPDATE`, idPO).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.NotFound("purchase order not found")
+		}
+		return err
+	}
+	if status != entity.POSent && status != entity.POPartiallyReceived {
+		return apperror.Conflict("purchase order %s berstatus %s dan tidak bisa diterima", idPO, status)
+	}
+
+	poDetails, err := listPurchaseOrderDetail(tx, idPO)
+	if err != nil {
+		return err
+	}
+	lines := make(map[string]entity.PurchaseOrderDetail, len(poDetails))
+	for _, line := range poDetails {
+		lines[line.IDPODetail] = line
+	}
+
+	received := make(map[string]int)
+	for _, detail := range details {
+		if _, ok := lines[detail.IDPODetail]; !ok {
+			return apperror.Validation("detail %s bukan bagian dari purchase order %s", detail.IDPODetail, idPO)
+		}
+		received[detail.IDPODetail] += detail.Qty
+	}
+
+	for i := range details {
+		line := lines[details[i].IDPODetail]
+		total := line.QtyDiterima + received[line.IDPODetail]
+		allowed := line.Qty * (100 + tolerancePercent) / 100
+		if total > allowed {
+			return apperror.Conflict("penerimaan detail %s melebihi toleransi: dipesan %d, sudah diterima %d, diterima sekarang %d",
+				line.IDPODetail, line.Qty, line.QtyDiterima, received[line.IDPODetail])
+		}
+		details[i].OverReceipt = total > line.Qty
+	}
+
+	for idPODetail, qty := range received {
+		_, err = tx.Exec(`UPDATE purchase_order_detail SET qty_diterima = qty_diterima + $2 WHERE id_po_detail = $1`, idPODetail, qty)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `
+        UPDATE purchase_order SET status = CASE
+            WHEN EXISTS (SELECT 1 FROM purchase_order_detail WHERE id_po = $1 AND qty_diterima < qty) THEN $2
+            ELSE $3
+        END
+        WHERE id_po = $1
+    `
+	_, err = tx.Exec(query, idPO, entity.POPartiallyReceived, entity.POReceived)
+	return err
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) PurchaseOrderRepository {
+	return &purchaseOrderRepository{db: db}
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"roxy/entity"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ReportRepository interface {
+	InventoryValuation(asOf time.Time) (entity.InventoryValuation, error)
+	GrossProfit(filter entity.GrossProfitFilter) ([]entity.GrossProfitRow, error)
+	SalesSummary(filter entity.SalesSummaryFilter) ([]entity.SalesSummaryRow, error)
+	TopItems(filter entity.TopItemFilter) ([]entity.TopItemRow, error)
+	SlowMovers(since time.Time) ([]entity.SlowMoverRow, error)
+	ReplenishmentInputs(since time.Time) ([]entity.ReplenishmentInput, error)
+}
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+// InventoryValuation values every barang at the last ledger movement booked
+// before asOf, so past dates give the stock value as it was then.
+func (r *reportRepository) InventoryValuation(asOf time.Time) (entity.InventoryValuation, error) {
+	valuation := entity.InventoryValuation{Tanggal: asOf}
+
+	query := `
+        SELECT b.id_barang, b.nm_barang, b.metode_hpp, m.saldo, m.nilai_saldo
+        FROM master_barang b
+        JOIN LATERAL (
+            SELECT saldo, nilai_saldo FROM stock_movement
+            WHERE id_barang = b.id_barang AND created_at < $1
+            ORDER BY id_movement DESC LIMIT 1
+        ) m ON TRUE
+        WHERE m.saldo <> 0 OR m.nilai_saldo <> 0
+        ORDER BY b.id_barang
+    `
+	rows, err := r.db.Query(query, asOf)
+	if err != nil {
+		return valuation, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entity.ValuationItem
+		if err := rows.Scan(&item.IDBarang, &item.NmBarang, &item.MetodeHpp, &item.Qty, &item.Nilai); err != nil {
+			return valuation, err
+		}
+		if item.Qty != 0 {
+			item.HargaPokok = item.Nilai.Div(int64(item.Qty))
+		}
+		valuation.Items = append(valuation.Items, item)
+		valuation.TotalNilai += item.Nilai
+	}
+
+	return valuation, rows.Err()
+}
+
+// soldLinesQuery selects the sold lines of transaksi net of whatever was
+// returned on them. The diskon of a transaksi is spread over its lines by
+// subtotal and pajak is not revenue, so pendapatan is what the line really
+// earned. tgl_lokal is tgl_trans in the timezone given to soldLinesWhere.
+// Combine it with soldLinesWhere.
+const soldLinesQuery = `
+    SELECT d.id_barang, b.nm_barang, b.kategori,
+        (h.tgl_trans AT TIME ZONE 'UTC') AT TIME ZONE $2 AS tgl_lokal,
+        d.qty - COALESCE(r.qty, 0) AS qty,
+        COALESCE(ROUND((d.subtotal - COALESCE(r.subtotal, 0)) * (h.total - h.pajak)
+            / NULLIF(h.total - h.pajak + h.diskon, 0), 2), 0) AS pendapatan,
+        d.hpp - COALESCE(r.hpp, 0) AS hpp
+    FROM transaksi_detail d
+    JOIN transaksi_header h ON h.id_trans = d.id_trans
+    JOIN master_barang b ON b.id_barang = d.id_barang
+    LEFT JOIN (
+        SELECT id_trans_detail, SUM(qty) AS qty, SUM(subtotal) AS subtotal, SUM(hpp) AS hpp
+        FROM retur_detail GROUP BY id_trans_detail
+    ) r ON r.id_trans_detail = d.id_trans_detail
+`
+
+// soldLinesWhere limits soldLinesQuery to active transaksi in [from, to),
+// either bound being optional. from and to are local times in the IANA
+// timezone tz, as tgl_trans is stored in UTC.
+func soldLinesWhere(from, to time.Time, tz string) (string, []any) {
+	args := []any{entity.TransaksiActive, tz}
+	conditions := []string{"h.status = $1"}
+	if !from.IsZero() {
+		args = append(args, from)
+		conditions = append(conditions, fmt.Sprintf("(h.tgl_trans AT TIME ZONE 'UTC') AT TIME ZONE $2 >= $%d", len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		conditions = append(conditions, fmt.Sprintf("(h.tgl_trans AT TIME ZONE 'UTC') AT TIME ZONE $2 < $%d", len(args)))
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// grossProfitGroups maps a grouping to its grup and nama expressions.
+var grossProfitGroups = map[string][2]string{
+	entity.ReportByItem:     {"id_barang", "nm_barang"},
+	entity.ReportByKategori: {"kategori", "kategori"},
+	entity.ReportByDay:      {"to_char(date_trunc('day', tgl_lokal), 'YYYY-MM-DD')", "''"},
+	entity.ReportByWeek:     {"to_char(date_trunc('week', tgl_lokal), 'YYYY-MM-DD')", "''"},
+	entity.ReportByMonth:    {"to_char(date_trunc('month', tgl_lokal), 'YYYY-MM-DD')", "''"},
+}
+
+// GrossProfit aggregates the sold lines of active transaksi using the hpp
+// recorded on each line at sale time. Days, weeks and months, and From/To,
+// are local to filter.Timezone.
+func (r *reportRepository) GrossProfit(filter entity.GrossProfitFilter) ([]entity.GrossProfitRow, error) {
+	var report []entity.GrossProfitRow
+
+	group, ok := grossProfitGroups[filter.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown group %q", filter.GroupBy)
+	}
+	if !slices.Contains(entity.GrossProfitColumns, filter.SortBy) {
+		return nil, fmt.Errorf("unknown sort column %q", filter.SortBy)
+	}
+
+	where, args := soldLinesWhere(filter.From, filter.To, filter.Timezone)
+
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	query := `
+        WITH lines AS (` + soldLinesQuery + where + `)
+        SELECT ` + group[0] + ` AS grup, ` + group[1] + ` AS nama,
+            SUM(qty) AS qty, SUM(pendapatan) AS pendapatan, SUM(hpp) AS hpp,
+            SUM(pendapatan - hpp) AS laba_kotor,
+            CASE WHEN SUM(pendapatan) = 0 THEN 0
+                ELSE ROUND(SUM(pendapatan - hpp) * 100 / SUM(pendapatan), 2) END AS margin_persen
+        FROM lines
+        GROUP BY 1, 2
+        ORDER BY ` + filter.SortBy + ` ` + direction + `, grup
+    `
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row entity.GrossProfitRow
+		err := rows.Scan(&row.Grup, &row.Nama, &row.Qty, &row.Pendapatan, &row.Hpp, &row.LabaKotor, &row.MarginPersen)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+// SalesSummary buckets active transaksi by the local day, week or month of
+// tgl_trans in filter.Timezone. tgl_trans is the moment of the sale in UTC,
+// and From/To are local dates in the same timezone. A transaksi entered with
+// a date only sits at midnight UTC of that date.
+func (r *reportRepository) SalesSummary(filter entity.SalesSummaryFilter) ([]entity.SalesSummaryRow, error) {
+	var report []entity.SalesSummaryRow
+
+	args := []any{filter.Interval, filter.Timezone, entity.TransaksiActive}
+	conditions := []string{"h.status = $3"}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("(h.tgl_trans AT TIME ZONE 'UTC') AT TIME ZONE $2 >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("(h.tgl_trans AT TIME ZONE 'UTC') AT TIME ZONE $2 < $%d", len(args)))
+	}
+
+	query := `
+        WITH trans AS (
+            SELECT h.id_trans,
+                date_trunc($1, (h.tgl_trans AT TIME ZONE 'UTC') AT TIME ZONE $2) AS periode,
+                h.diskon, h.pajak, h.total,
+                COALESCE(SUM(d.subtotal), 0) AS bruto,
+                COALESCE(SUM(d.qty), 0) AS item
+            FROM transaksi_header h
+            LEFT JOIN transaksi_detail d ON d.id_trans = h.id_trans
+            WHERE ` + strings.Join(conditions, " AND ") + `
+            GROUP BY h.id_trans
+        )
+        SELECT to_char(periode, 'YYYY-MM-DD'), COUNT(*), SUM(item), SUM(bruto), SUM(diskon), SUM(pajak), SUM(total)
+        FROM trans
+        GROUP BY periode
+        ORDER BY periode
+    `
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row entity.SalesSummaryRow
+		err := rows.Scan(&row.Periode, &row.JumlahTransaksi, &row.JumlahItem, &row.Bruto, &row.Diskon, &row.Pajak, &row.Neto)
+		if err != nil {
+			return nil, err
+		}
+		row.RataRata = row.Neto.Div(int64(row.JumlahTransaksi))
+		row.RataRataItem = math.Round(float64(row.JumlahItem)*100/float64(row.JumlahTransaksi)) / 100
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+// TopItems ranks barang by qty or pendapatan sold in the window. Ties are
+// broken by the other measure and then by id_barang.
+func (r *reportRepository) TopItems(filter entity.TopItemFilter) ([]entity.TopItemRow, error) {
+	var report []entity.TopItemRow
+
+	order := "qty DESC, pendapatan DESC"
+	switch filter.SortBy {
+	case "qty":
+	case "pendapatan":
+		order = "pendapatan DESC, qty DESC"
+	default:
+		return nil, fmt.Errorf("unknown sort column %q", filter.SortBy)
+	}
+
+	where, args := soldLinesWhere(filter.From, filter.To, "UTC")
+	args = append(args, filter.Limit)
+
+	query := `
+        WITH lines AS (` + soldLinesQuery + where + `)
+        SELECT id_barang, nm_barang, SUM(qty) AS qty, SUM(pendapatan) AS pendapatan
+        FROM lines
+        GROUP BY id_barang, nm_barang
+        ORDER BY ` + order + `, id_barang
+        LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := entity.TopItemRow{Peringkat: len(report) + 1}
+		if err := rows.Scan(&row.IDBarang, &row.NmBarang, &row.Qty, &row.Pendapatan); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+// SlowMovers lists barang with stock on hand that were not sold on an active
+// transaksi since the given time, longest unsold first.
+func (r *reportRepository) SlowMovers(since time.Time) ([]entity.SlowMoverRow, error) {
+	var report []entity.SlowMoverRow
+
+	query := `
+        SELECT b.id_barang, b.nm_barang, b.qty, b.harga_pokok * b.qty, s.terakhir
+        FROM master_barang b
+        LEFT JOIN (
+            SELECT d.id_barang, MAX(h.tgl_trans) AS terakhir
+            FROM transaksi_detail d
+            JOIN transaksi_header h ON h.id_trans = d.id_trans
+            WHERE h.status = $1
+            GROUP BY d.id_barang
+        ) s ON s.id_barang = b.id_barang
+        WHERE b.qty > 0 AND (s.terakhir IS NULL OR s.terakhir < $2)
+        ORDER BY s.terakhir NULLS FIRST, b.id_barang
+    `
+	rows, err := r.db.Query(query, entity.TransaksiActive, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row entity.SlowMoverRow
+		if err := rows.Scan(&row.IDBarang, &row.NmBarang, &row.Qty, &row.NilaiStok, &row.TerakhirTerjual); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+// ReplenishmentInputs collects, for every barang, the qty sold since the given
+// time net of returns and the qty still outstanding on purchase orders that
+// were sent but not fully received.
+func (r *reportRepository) ReplenishmentInputs(since time.Time) ([]entity.ReplenishmentInput, error) {
+	var inputs []entity.ReplenishmentInput
+
+	where, args := soldLinesWhere(since, time.Time{}, "UTC")
+	args = append(args, pq.Array([]string{entity.POSent, entity.POPartiallyReceived}))
+
+	query := `
+        WITH lines AS (` + soldLinesQuery + where + `),
+        sold AS (
+            SELECT id_barang, SUM(qty) AS qty FROM lines GROUP BY id_barang
+        ),
+        on_order AS (
+            SELECT d.id_barang, SUM(GREATEST(d.qty - d.qty_diterima, 0)) AS qty
+            FROM purchase_order_detail d JOIN purchase_order p ON p.id_po = d.id_po
+            WHERE p.status = ANY($` + strconv.Itoa(len(args)) + `)
+            GROUP BY d.id_barang
+        )
+        SELECT b.id_barang, b.nm_barang, b.qty, COALESCE(o.qty, 0), COALESCE(s.qty, 0),
+            b.lead_time_hari, b.safety_stock, b.reorder_qty, b.harga_pokok
+        FROM master_barang b
+        LEFT JOIN sold s ON s.id_barang = b.id_barang
+        LEFT JOIN on_order o ON o.id_barang = b.id_barang
+        ORDER BY b.id_barang
+    `
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var input entity.ReplenishmentInput
+		err := rows.Scan(&input.IDBarang, &input.NmBarang, &input.Qty, &input.DalamPesanan, &input.Terjual,
+			&input.LeadTimeHari, &input.SafetyStock, &input.ReorderQty, &input.HargaPokok)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+
+	return inputs, rows.Err()
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
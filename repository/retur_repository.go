@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+)
+
+type ReturRepository interface {
+	CreateRetur(actor entity.User, header entity.ReturHeader, details []entity.ReturDetail) (entity.ReturHeader, []entity.ReturDetail, error)
+	ListReturByTransaksi(idTrans string) ([]entity.ReturHeader, error)
+	GetReturByID(idRetur string) (entity.ReturHeader, []entity.ReturDetail, error)
+}
+
+type returRepository struct {
+	db *sql.DB
+}
+
+// CreateRetur validates the returned lines against what was sold minus what was
+// already returned while holding the transaksi row lock, so two returns on the
+// same sale cannot both pass the check. Harga comes from the sold line, and
+// the refund is what the customer actually paid for it after diskon and pajak.
+func (r *returRepository) CreateRetur(actor entity.User, header entity.ReturHeader, details []entity.ReturDetail) (entity.ReturHeader, []entity.ReturDetail, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return header, details, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveTransaksi(tx, header.IDTrans); err != nil {
+		return header, details, err
+	}
+
+	soldDetails, err := listTransaksiDetail(tx, header.IDTrans)
+	if err != nil {
+		return header, details, err
+	}
+	sold := make(map[string]entity.TransaksiDetail, len(soldDetails))
+	for _, detail := range soldDetails {
+		sold[detail.IDTransDetail] = detail
+	}
+
+	returned, err := returnedQty(tx, header.IDTrans)
+	if err != nil {
+		return header, details, err
+	}
+
+	// refunds are paid out of the drawer of whoever hands them out
+	header.IDShift, err = lockOpenShift(tx, actor.Username)
+	if err != nil {
+		return header, details, err
+	}
+
+	var diskon, pajak, total money.Money
+	err = tx.QueryRow(`SELECT diskon, pajak, total FROM transaksi_header WHERE id_trans = $1`, header.IDTrans).Scan(&diskon, &pajak, &total)
+	if err != nil {
+		return header, details, err
+	}
+	bruto := total + diskon - pajak
+
+	header.TotalRefund = 0
+	for i := range details {
+		line, ok := sold[details[i].IDTransDetail]
+		if !ok {
+			return header, details, apperror.Validation("detail %s bukan bagian dari transaksi %s", details[i].IDTransDetail, header.IDTrans)
+		}
+
+		returned[line.IDTransDetail] += details[i].Qty
+		if returned[line.IDTransDetail] > line.Qty {
+			return header, details, apperror.Conflict("qty retur detail %s melebihi qty terjual: terjual %d, sudah diretur %d",
+				line.IDTransDetail, line.Qty, returned[line.IDTransDetail]-details[i].Qty)
+		}
+
+		details[i].IDBarang = line.IDBarang
+		details[i].Harga = line.Harga
+		details[i].Subtotal = line.Harga.Mul(details[i].Qty)
+		details[i].Hpp = line.Hpp.MulRatio(int64(details[i].Qty), int64(line.Qty))
+		header.TotalRefund += details[i].Subtotal.MulRatio(int64(total), int64(bruto))
+	}
+
+	queryHeader := `
+        INSERT INTO retur_header (id_trans, id_shift, tgl_retur, alasan, total_refund)
+        VALUES ($1, $2, $3, $4, $5) RETURNING id_retur
+    `
+	err = tx.QueryRow(queryHeader, header.IDTrans, header.IDShift, header.TglRetur, header.Alasan, header.TotalRefund).Scan(&header.IDRetur)
+	if err != nil {
+		return header, details, err
+	}
+
+	for i := range details {
+		details[i].IDRetur = header.IDRetur
+
+		queryDetail := `
+            INSERT INTO retur_detail (id_retur, id_trans_detail, id_barang, qty, harga, subtotal, hpp)
+            VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id_retur_detail
+        `
+		err = tx.QueryRow(queryDetail, details[i].IDRetur, details[i].IDTransDetail, details[i].IDBarang, details[i].Qty, details[i].Harga, details[i].Subtotal, details[i].Hpp).Scan(&details[i].IDReturDetail)
+		if err != nil {
+			return header, details, err
+		}
+
+		_, err = applyStockMovement(tx, entity.StockMovement{
+			IDBarang:   details[i].IDBarang,
+			Tipe:       entity.MovementReturn,
+			Qty:        details[i].Qty,
+			HargaPokok: details[i].Hpp.Div(int64(details[i].Qty)),
+			RefID:      header.IDRetur,
+			Keterangan: header.Alasan,
+		})
+		if err != nil {
+			return header, details, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return header, details, err
+	}
+
+	return header, details, nil
+}
+
+func (r *returRepository) ListReturByTransaksi(idTrans string) ([]entity.ReturHeader, error) {
+	var returs []entity.ReturHeader
+
+	query := `SELECT id_retur, id_trans, COALESCE(id_shift, ''), tgl_retur, alasan, total_refund FROM retur_header WHERE id_trans = $1 ORDER BY id_retur`
+	rows, err := r.db.Query(query, idTrans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var retur entity.ReturHeader
+		err := rows.Scan(&retur.IDRetur, &retur.IDTrans, &retur.IDShift, &retur.TglRetur, &retur.Alasan, &retur.TotalRefund)
+		if err != nil {
+			return nil, err
+		}
+		returs = append(returs, retur)
+	}
+
+	return returs, rows.Err()
+}
+
+func (r *returRepository) GetReturByID(idRetur string) (entity.ReturHeader, []entity.ReturDetail, error) {
+	var header entity.ReturHeader
+	var details []entity.ReturDetail
+
+	queryHeader := `SELECT id_retur, id_trans, COALESCE(id_shift, ''), tgl_retur, alasan, total_refund FROM retur_header WHERE id_retur = $1`
+	err := r.db.QueryRow(queryHeader, idRetur).Scan(&header.IDRetur, &header.IDTrans, &header.IDShift, &header.TglRetur, &header.Alasan, &header.TotalRefund)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return header, details, apperror.NotFound("retur not found")
+		}
+		return header, details, err
+	}
+
+	queryDetail := `SELECT id_retur_detail, id_retur, id_trans_detail, id_barang, qty, harga, subtotal, hpp FROM retur_detail WHERE id_retur = $1 ORDER BY id_retur_detail`
+	rows, err := r.db.Query(queryDetail, idRetur)
+	if err != nil {
+		return header, details, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail entity.ReturDetail
+		err := rows.Scan(&detail.IDReturDetail, &detail.IDRetur, &detail.IDTransDetail, &detail.IDBarang, &detail.Qty, &detail.Harga, &detail.Subtotal, &detail.Hpp)
+		if err != nil {
+			return header, details, err
+		}
+		details = append(details, detail)
+	}
+
+	return header, details, rows.Err()
+}
+
+// returnedQty sums the qty already returned per id_trans_detail of a transaksi.
+func returnedQty(q queryer, idTrans string) (map[string]int, error) {
+	query := `
+        SELECT rd.id_trans_detail, SUM(rd.qty)
+        FROM retur_detail rd JOIN retur_header rh ON rh.id_retur = rd.id_retur
+        WHERE rh.id_trans = $1
+        GROUP BY rd.id_trans_detail
+    `
+	rows, err := q.Query(query, idTrans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returned := make(map[string]int)
+	for rows.Next() {
+		var (
+			idTransDetail string
+			qty           int
+		)
+		if err := rows.Scan(&idTransDetail, &qty); err != nil {
+			return nil, err
+		}
+		returned[idTransDetail] = qty
+	}
+
+	return returned, rows.Err()
+}
+
+func NewReturRepository(db *sql.DB) ReturRepository {
+	return &returRepository{db: db}
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+
+	"github.com/lib/pq"
+)
+
+type ShiftRepository interface {
+	Open(shift entity.Shift) (entity.Shift, error)
+	GetOpen(kasir string) (entity.Shift, error)
+	GetByID(idShift string) (entity.Shift, error)
+	Close(idShift string, kasDihitung money.Money) (entity.Shift, error)
+	ZReport(idShift string) (entity.ZReport, error)
+}
+
+type shiftRepository struct {
+	db *sql.DB
+}
+
+const shiftColumns = `id_shift, kasir, saldo_awal, kas_dihitung, status, opened_at, closed_at`
+
+func scanShift(row rowScanner) (entity.Shift, error) {
+	var shift entity.Shift
+	err := row.Scan(&shift.IDShift, &shift.Kasir, &shift.SaldoAwal, &shift.KasDihitung, &shift.Status, &shift.OpenedAt, &shift.ClosedAt)
+	return shift, err
+}
+
+func (s *shiftRepository) Open(shift entity.Shift) (entity.Shift, error) {
+	query := `INSERT INTO kasir_shift (kasir, saldo_awal, status) VALUES ($1, $2, $3) RETURNING ` + shiftColumns
+	opened, err := scanShift(s.db.QueryRow(query, shift.Kasir, shift.SaldoAwal, entity.ShiftOpen))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation && pqErr.Constraint == "ux_kasir_shift_open" {
+			return entity.Shift{}, apperror.Conflict("kasir %s masih punya shift yang terbuka", shift.Kasir)
+		}
+		return entity.Shift{}, err
+	}
+
+	return opened, nil
+}
+
+func (s *shiftRepository) GetOpen(kasir string) (entity.Shift, error) {
+	shift, err := scanShift(s.db.QueryRow(`SELECT `+shiftColumns+` FROM kasir_shift WHERE kasir = $1 AND status = $2`, kasir, entity.ShiftOpen))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Shift{}, apperror.NotFound("open shift of kasir %s not found", kasir)
+		}
+		return entity.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+func (s *shiftRepository) GetByID(idShift string) (entity.Shift, error) {
+	shift, err := scanShift(s.db.QueryRow(`SELECT `+shiftColumns+` FROM kasir_shift WHERE id_shift = $1`, idShift))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Shift{}, apperror.NotFound("shift not found")
+		}
+		return entity.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+// Close takes the same row lock as lockOpenShift, so it waits for sales that
+// are still being written against the shift.
+func (s *shiftRepository) Close(idShift string, kasDihitung money.Money) (entity.Shift, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return entity.Shift{}, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM kasir_shift WHERE id_shift = $1 FOR UPDATE`, idShift).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Shift{}, apperror.NotFound("shift not found")
+		}
+		return entity.Shift{}, err
+	}
+	if status != entity.ShiftOpen {
+		return entity.Shift{}, apperror.Conflict("shift %s sudah ditutup", idShift)
+	}
+
+	query := `UPDATE kasir_shift SET status = $2, kas_dihitung = $3, closed_at = NOW() WHERE id_shift = $1 RETURNING ` + shiftColumns
+	shift, err := scanShift(tx.QueryRow(query, idShift, entity.ShiftClosed, kasDihitung))
+	if err != nil {
+		return entity.Shift{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+// ZReport summarizes a shift. Expected cash is the opening float plus cash
+// taken on sales that are still active, minus change given and minus the
+// refunds of returns. Every refund is paid in cash out of the drawer, also on
+// sales paid by card or transfer, so all of them come off.
+func (s *shiftRepository) ZReport(idShift string) (entity.ZReport, error) {
+	var report entity.ZReport
+
+	shift, err := s.GetByID(idShift)
+	if err != nil {
+		return report, err
+	}
+	report.Shift = shift
+
+	query := `
+        SELECT
+            COUNT(*) FILTER (WHERE status = $2),
+            COALESCE(SUM(total) FILTER (WHERE status = $2), 0),
+            COUNT(*) FILTER (WHERE status = $3),
+            COALESCE(SUM(total) FILTER (WHERE status = $3), 0)
+        FROM transaksi_header WHERE id_shift = $1
+    `
+	err = s.db.QueryRow(query, idShift, entity.TransaksiActive, entity.TransaksiVoided).Scan(&report.JumlahPenjualan, &report.TotalPenjualan, &report.JumlahVoid, &report.TotalVoid)
+	if err != nil {
+		return report, err
+	}
+
+	err = s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(total_refund), 0) FROM retur_header WHERE id_shift = $1`, idShift).Scan(&report.JumlahRetur, &report.TotalRetur)
+	if err != nil {
+		return report, err
+	}
+
+	queryMetode := `
+        SELECT p.metode, COUNT(*), SUM(p.jumlah - p.kembalian)
+        FROM transaksi_payment p JOIN transaksi_header h ON h.id_trans = p.id_trans
+        WHERE p.id_shift = $1 AND h.status = $2
+        GROUP BY p.metode ORDER BY p.metode
+    `
+	rows, err := s.db.Query(queryMetode, idShift, entity.TransaksiActive)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	var cashIn money.Money
+	for rows.Next() {
+		var total entity.PaymentMethodTotal
+		if err := rows.Scan(&total.Metode, &total.Jumlah, &total.Total); err != nil {
+			return report, err
+		}
+		if total.Metode == entity.PaymentCash {
+			cashIn = total.Total
+		}
+		report.PerMetode = append(report.PerMetode, total)
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	report.KasDiharapkan = shift.SaldoAwal + cashIn - report.TotalRetur
+	report.KasDihitung = shift.KasDihitung
+	if shift.Status == entity.ShiftClosed {
+		report.Selisih = report.KasDihitung - report.KasDiharapkan
+	}
+
+	return report, nil
+}
+
+// lockOpenShift returns the open shift of the kasir, which every cash movement
+// they make is booked on. The shared lock keeps the shift from being closed
+// until the caller commits.
+func lockOpenShift(tx *sql.Tx, kasir string) (string, error) {
+	var idShift string
+	err := tx.QueryRow(`SELECT id_shift FROM kasir_shift WHERE kasir = $1 AND status = $2 FOR SHARE`, kasir, entity.ShiftOpen).Scan(&idShift)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", apperror.Conflict("kasir %s tidak punya shift yang terbuka", kasir)
+		}
+		return "", err
+	}
+	return idShift, nil
+}
+
+func NewShiftRepository(db *sql.DB) ShiftRepository {
+	return &shiftRepository{db: db}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+	"roxy/entity"
+)
+
+type StockAlertRepository interface {
+	ListPending(maxAttempts int, limit int) ([]entity.StockAlert, error)
+	MarkSent(idAlert int64) error
+	MarkFailed(idAlert int64, reason string) error
+}
+
+type stockAlertRepository struct {
+	db *sql.DB
+}
+
+// ListPending returns unsent alerts that have been tried fewer than
+// maxAttempts times, oldest first.
+func (s *stockAlertRepository) ListPending(maxAttempts int, limit int) ([]entity.StockAlert, error) {
+	var alerts []entity.StockAlert
+
+	query := `
+        SELECT a.id_alert, a.id_barang, b.nm_barang, a.id_movement, a.qty, a.reorder_point, b.reorder_qty, a.attempts, a.created_at
+        FROM stock_alert a JOIN master_barang b ON b.id_barang = a.id_barang
+        WHERE a.sent_at IS NULL AND a.attempts < $1
+        ORDER BY a.id_alert LIMIT $2
+    `
+	rows, err := s.db.Query(query, maxAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alert entity.StockAlert
+		err := rows.Scan(&alert.IDAlert, &alert.IDBarang, &alert.NmBarang, &alert.IDMovement, &alert.Qty, &alert.ReorderPoint, &alert.ReorderQty, &alert.Attempts, &alert.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+func (s *stockAlertRepository) MarkSent(idAlert int64) error {
+	_, err := s.db.Exec(`UPDATE stock_alert SET sent_at = NOW(), attempts = attempts + 1, last_error = '' WHERE id_alert = $1`, idAlert)
+	return err
+}
+
+func (s *stockAlertRepository) MarkFailed(idAlert int64, reason string) error {
+	_, err := s.db.Exec(`UPDATE stock_alert SET attempts = attempts + 1, last_error = $2 WHERE id_alert = $1`, idAlert, reason)
+	return err
+}
+
+func NewStockAlertRepository(db *sql.DB) StockAlertRepository {
+	return &stockAlertRepository{db: db}
+}
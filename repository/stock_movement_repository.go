@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"database/sql"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+type StockMovementRepository interface {
+	Create(movement entity.StockMovement) (entity.StockMovement, error)
+	ListByBarang(idBarang string) ([]entity.StockMovement, error)
+}
+
+type stockMovementRepository struct {
+	db *sql.DB
+}
+
+func (s *stockMovementRepository) Create(movement entity.StockMovement) (entity.StockMovement, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return entity.StockMovement{}, err
+	}
+	defer tx.Rollback()
+
+	if movement.Qty < 0 {
+		if err := reserveStock(tx, map[string]int{movement.IDBarang: -movement.Qty}); err != nil {
+			return entity.StockMovement{}, err
+		}
+	}
+
+	movement, err = applyStockMovement(tx, movement)
+	if err != nil {
+		return entity.StockMovement{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.StockMovement{}, err
+	}
+
+	return movement, nil
+}
+
+func (s *stockMovementRepository) ListByBarang(idBarang string) ([]entity.StockMovement, error) {
+	var movements []entity.StockMovement
+
+	query := `SELECT id_movement, id_barang, tipe, qty, saldo, harga_pokok, nilai, nilai_saldo, ref_id, keterangan, created_at
+        FROM stock_movement WHERE id_barang = $1 ORDER BY id_movement`
+
+	rows, err := s.db.Query(query, idBarang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movement entity.StockMovement
+		err := rows.Scan(&movement.IDMovement, &movement.IDBarang, &movement.Tipe, &movement.Qty, &movement.Saldo, &movement.HargaPokok, &movement.Nilai, &movement.NilaiSaldo, &movement.RefID, &movement.Keterangan, &movement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+
+	return movements, rows.Err()
+}
+
+// applyStockMovement is the only place allowed to change master_barang.qty.
+// The UPDATE locks the barang row, so the saldo written to the ledger is the
+// running balance right after this movement, even under concurrent writers.
+//
+// It also keeps the cost of the barang. Incoming qty is costed at
+// movement.HargaPokok (anything but a purchase without a cost falls back to
+// the current harga_pokok), updates the moving average and opens a FIFO cost
+// layer. Outgoing qty always consumes the oldest layers, and is costed by
+// those layers for fifo barang or by the moving average otherwise. Qty going
+// out beyond the layers, into negative stock, is costed at the average.
+//
+// A movement that takes qty below the reorder point of the barang queues a
+// stock alert, which is only sent once the transaction commits.
+func applyStockMovement(tx *sql.Tx, movement entity.StockMovement) (entity.StockMovement, error) {
+	var (
+		hargaPokok   money.Money
+		metodeHpp    string
+		reorderPoint int
+	)
+	query := `UPDATE master_barang SET qty = qty + $2 WHERE id_barang = $1 RETURNING qty, harga_pokok, metode_hpp, reorder_point`
+	err := tx.QueryRow(query, movement.IDBarang, movement.Qty).Scan(&movement.Saldo, &hargaPokok, &metodeHpp, &reorderPoint)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return movement, apperror.NotFound("barang with ID %s not found", movement.IDBarang)
+		}
+		return movement, err
+	}
+
+	layerQty := 0
+	if movement.Qty > 0 {
+		if movement.HargaPokok == 0 && movement.Tipe != entity.MovementPurchase {
+			movement.HargaPokok = hargaPokok
+		}
+		movement.Nilai = movement.HargaPokok.Mul(movement.Qty)
+
+		hargaPokok = averageCost(hargaPokok, movement.Saldo-movement.Qty, movement)
+		if _, err := tx.Exec(`UPDATE master_barang SET harga_pokok = $2 WHERE id_barang = $1`, movement.IDBarang, hargaPokok); err != nil {
+			return movement, err
+		}
+
+		// qty that only fills up negative stock has nothing left to layer
+		layerQty = min(movement.Qty, max(movement.Saldo, 0))
+	} else if movement.Qty < 0 {
+		fifoCost, err := consumeCostLayers(tx, movement.IDBarang, -movement.Qty, hargaPokok)
+		if err != nil {
+			return movement, err
+		}
+
+		cost := hargaPokok.Mul(-movement.Qty)
+		if metodeHpp == entity.CostingFIFO {
+			cost = fifoCost
+		}
+		movement.Nilai = -cost
+		movement.HargaPokok = cost.Div(int64(-movement.Qty))
+	}
+
+	if metodeHpp == entity.CostingFIFO {
+		err = tx.QueryRow(`SELECT COALESCE(SUM(qty_sisa * harga_pokok), 0) FROM cost_layer WHERE id_barang = $1`, movement.IDBarang).Scan(&movement.NilaiSaldo)
+		if err != nil {
+			return movement, err
+		}
+		movement.NilaiSaldo += movement.HargaPokok.Mul(layerQty)
+		if movement.Saldo < 0 {
+			movement.NilaiSaldo += hargaPokok.Mul(movement.Saldo)
+		}
+	} else {
+		movement.NilaiSaldo = hargaPokok.Mul(movement.Saldo)
+	}
+
+	query = `
+        INSERT INTO stock_movement (id_barang, tipe, qty, saldo, harga_pokok, nilai, nilai_saldo, ref_id, keterangan)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id_movement, created_at
+    `
+	err = tx.QueryRow(query, movement.IDBarang, movement.Tipe, movement.Qty, movement.Saldo, movement.HargaPokok, movement.Nilai, movement.NilaiSaldo, movement.RefID, movement.Keterangan).Scan(&movement.IDMovement, &movement.CreatedAt)
+	if err != nil {
+		return movement, err
+	}
+
+	if layerQty > 0 {
+		_, err = tx.Exec(`INSERT INTO cost_layer (id_barang, id_movement, qty_sisa, harga_pokok) VALUES ($1, $2, $3, $4)`,
+			movement.IDBarang, movement.IDMovement, layerQty, movement.HargaPokok)
+		if err != nil {
+			return movement, err
+		}
+	}
+
+	qtyBefore := movement.Saldo - movement.Qty
+	if reorderPoint > 0 && qtyBefore >= reorderPoint && movement.Saldo < reorderPoint {
+		_, err = tx.Exec(`INSERT INTO stock_alert (id_barang, id_movement, qty, reorder_point) VALUES ($1, $2, $3, $4)`,
+			movement.IDBarang, movement.IDMovement, movement.Saldo, reorderPoint)
+		if err != nil {
+			return movement, err
+		}
+	}
+
+	return movement, nil
+}
+
+// consumeCostLayers takes qty out of the oldest cost layers of a barang and
+// returns what that qty cost. Qty not covered by any layer is costed at
+// fallback.
+func consumeCostLayers(tx *sql.Tx, idBarang string, qty int, fallback money.Money) (money.Money, error) {
+	rows, err := tx.Query(`SELECT id_layer, qty_sisa, harga_pokok FROM cost_layer
+        WHERE id_barang = $1 AND qty_sisa > 0 ORDER BY id_layer FOR UPDATE`, idBarang)
+	if err != nil {
+		return 0, err
+	}
+
+	var layers []costLayer
+	for rows.Next() {
+		var l costLayer
+		if err := rows.Scan(&l.id, &l.qty, &l.harga); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		layers = append(layers, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	cost, taken := takeFromLayers(layers, qty, fallback)
+	for i, l := range layers {
+		if taken[i] == 0 {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE cost_layer SET qty_sisa = qty_sisa - $2 WHERE id_layer = $1`, l.id, taken[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	return cost, nil
+}
+
+type costLayer struct {
+	id    int64
+	qty   int
+	harga money.Money
+}
+
+// takeFromLayers works out how much of qty comes out of each layer, oldest
+// first, and what that qty cost. Qty not covered by the layers is costed at
+// fallback.
+func takeFromLayers(layers []costLayer, qty int, fallback money.Money) (money.Money, []int) {
+	var cost money.Money
+	taken := make([]int, len(layers))
+	for i, l := range layers {
+		if qty == 0 {
+			break
+		}
+		taken[i] = min(qty, l.qty)
+		cost += l.harga.Mul(taken[i])
+		qty -= taken[i]
+	}
+	return cost + fallback.Mul(qty), taken
+}
+
+// averageCost is the moving average harga_pokok after an incoming movement
+// onto qtyBefore at hargaPokok. Stock that was empty or negative has nothing
+// to average with, so the movement sets the cost.
+func averageCost(hargaPokok money.Money, qtyBefore int, movement entity.StockMovement) money.Money {
+	if qtyBefore <= 0 {
+		return movement.HargaPokok
+	}
+	return (hargaPokok.Mul(qtyBefore) + movement.Nilai).Div(int64(movement.Saldo))
+}
+
+// reserveStock locks the master_barang rows of every requested item and checks
+// that each can give out the requested qty. Rows are locked in id order so two
+// transactions touching the same items cannot deadlock each other. Items with
+// allow_negative_stock are locked but never rejected.
+func reserveStock(tx *sql.Tx, requested map[string]int) error {
+	ids := make([]string, 0, len(requested))
+	for id := range requested {
+		ids = append(ids, id)
+	}
+
+	query := `SELECT id_barang, qty, allow_negative_stock FROM master_barang
+        WHERE id_barang = ANY($1) ORDER BY id_barang FOR UPDATE`
+
+	rows, err := tx.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool, len(ids))
+	var insufficient []entity.InsufficientStock
+	for rows.Next() {
+		var (
+			id            string
+			qty           int
+			allowNegative bool
+		)
+		if err := rows.Scan(&id, &qty, &allowNegative); err != nil {
+			return err
+		}
+		found[id] = true
+
+		if !allowNegative && requested[id] > qty {
+			insufficient = append(insufficient, entity.InsufficientStock{IDBarang: id, Requested: requested[id], Available: qty})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		if !found[id] {
+			return apperror.NotFound("barang with ID %s not found", id)
+		}
+	}
+
+	if len(insufficient) > 0 {
+		return &entity.InsufficientStockError{Items: insufficient}
+	}
+
+	return nil
+}
+
+func NewStockMovementRepository(db *sql.DB) StockMovementRepository {
+	return &stockMovementRepository{db: db}
+}
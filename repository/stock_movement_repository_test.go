@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"roxy/entity"
+	"roxy/shared/money"
+	"slices"
+	"testing"
+)
+
+func TestTakeFromLayers(t *testing.T) {
+	layers := []costLayer{
+		{id: 1, qty: 5, harga: money.New(1000)},
+		{id: 2, qty: 3, harga: money.New(1200)},
+		{id: 3, qty: 10, harga: money.New(900)},
+	}
+
+	tests := []struct {
+		name     string
+		layers   []costLayer
+		qty      int
+		fallback money.Money
+		cost     money.Money
+		taken    []int
+	}{
+		{"nothing", layers, 0, money.New(1100), 0, []int{0, 0, 0}},
+		{"within the oldest layer", layers, 4, money.New(1100), money.New(4000), []int{4, 0, 0}},
+		{"all of the oldest layer", layers, 5, money.New(1100), money.New(5000), []int{5, 0, 0}},
+		{"into the next layers", layers, 10, money.New(1100), money.New(5000 + 3600 + 1800), []int{5, 3, 2}},
+		{"every layer", layers, 18, money.New(1100), money.New(5000 + 3600 + 9000), []int{5, 3, 10}},
+		{"beyond the layers at the fallback", layers, 20, money.New(1100), money.New(5000 + 3600 + 9000 + 2200), []int{5, 3, 10}},
+		{"no layers", nil, 3, money.New(1100), money.New(3300), []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, taken := takeFromLayers(tt.layers, tt.qty, tt.fallback)
+			if cost != tt.cost {
+				t.Errorf("cost = %s, want %s", cost, tt.cost)
+			}
+			if !slices.Equal(taken, tt.taken) {
+				t.Errorf("taken = %v, want %v", taken, tt.taken)
+			}
+		})
+	}
+}
+
+func TestAverageCost(t *testing.T) {
+	incoming := func(qty, saldo int, harga money.Money) entity.StockMovement {
+		return entity.StockMovement{Qty: qty, Saldo: saldo, HargaPokok: harga, Nilai: harga.Mul(qty)}
+	}
+
+	tests := []struct {
+		name       string
+		hargaPokok money.Money
+		qtyBefore  int
+		movement   entity.StockMovement
+		want       money.Money
+	}{
+		{"same cost", money.New(1000), 10, incoming(10, 20, money.New(1000)), money.New(1000)},
+		{"weighted by qty", money.New(1000), 10, incoming(30, 40, money.New(1200)), money.New(1150)},
+		{"rounded to the sen", money.New(1000), 2, incoming(1, 3, 1), 66667},
+		{"from empty stock", money.New(1000), 0, incoming(5, 5, money.New(1300)), money.New(1300)},
+		{"from negative stock", money.New(1000), -3, incoming(5, 2, money.New(1300)), money.New(1300)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := averageCost(tt.hargaPokok, tt.qtyBefore, tt.movement); got != tt.want {
+				t.Errorf("averageCost = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"database/sql"
+	"roxy/entity"
+	"roxy/shared/apperror"
+
+	"github.com/lib/pq"
+)
+
+type StockOpnameRepository interface {
+	CreateStockOpname(header entity.StockOpnameHeader, idBarang []string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error)
+	ListStockOpname() ([]entity.StockOpnameHeader, error)
+	GetStockOpnameByID(idOpname string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error)
+	RecordCounts(idOpname string, counts []entity.StockOpnameCount) error
+	ApproveStockOpname(idOpname string, kodeAlasan string) error
+	CancelStockOpname(idOpname string) error
+}
+
+type stockOpnameRepository struct {
+	db *sql.DB
+}
+
+// The variance value of an opname is not stored, it follows the counts that
+// have been recorded so far.
+const stockOpnameColumns = `h.id_opname, h.tgl_opname, h.status, h.catatan, h.kode_alasan,
+        COALESCE((SELECT SUM((d.qty_hitung - d.qty_sistem) * d.harga) FROM stock_opname_detail d
+            WHERE d.id_opname = h.id_opname AND d.qty_hitung IS NOT NULL), 0),
+        h.approved_at`
+
+func scanStockOpname(row rowScanner) (entity.StockOpnameHeader, error) {
+	var header entity.StockOpnameHeader
+	err := row.Scan(&header.IDOpname, &header.TglOpname, &header.Status, &header.Catatan, &header.KodeAlasan, &header.NilaiSelisih, &header.ApprovedAt)
+	return header, err
+}
+
+// CreateStockOpname snapshots qty and harga pokok of the given barang, or of
+// every barang when idBarang is empty.
+func (s *stockOpnameRepository) CreateStockOpname(header entity.StockOpnameHeader, idBarang []string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return header, nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`INSERT INTO stock_opname (tgl_opname, status, catatan) VALUES ($1, $2, $3) RETURNING id_opname`,
+		header.TglOpname, header.Status, header.Catatan).Scan(&header.IDOpname)
+	if err != nil {
+		return header, nil, err
+	}
+
+	query := `
+        INSERT INTO stock_opname_detail (id_opname, id_barang, qty_sistem, harga)
+        SELECT $1, id_barang, qty, harga_pokok FROM master_barang
+        WHERE COALESCE(cardinality($2::VARCHAR[]), 0) = 0 OR id_barang = ANY($2)
+        ORDER BY id_barang
+    `
+	res, err := tx.Exec(query, header.IDOpname, pq.Array(idBarang))
+	if err != nil {
+		return header, nil, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return header, nil, err
+	}
+	if inserted == 0 {
+		return header, nil, apperror.Validation("tidak ada barang untuk stock opname")
+	}
+	if len(idBarang) > 0 && int(inserted) != len(idBarang) {
+		return header, nil, apperror.NotFound("barang not found")
+	}
+
+	details, err := listStockOpnameDetail(tx, header.IDOpname)
+	if err != nil {
+		return header, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return header, nil, err
+	}
+
+	return header, details, nil
+}
+
+func (s *stockOpnameRepository) ListStockOpname() ([]entity.StockOpnameHeader, error) {
+	var opnames []entity.StockOpnameHeader
+
+	rows, err := s.db.Query(`SELECT ` + stockOpnameColumns + ` FROM stock_opname h ORDER BY h.tgl_opname, h.id_opname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		opname, err := scanStockOpname(rows)
+		if err != nil {
+			return nil, err
+		}
+		opnames = append(opnames, opname)
+	}
+
+	return opnames, rows.Err()
+}
+
+func (s *stockOpnameRepository) GetStockOpnameByID(idOpname string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error) {
+	header, err := scanStockOpname(s.db.QueryRow(`SELECT `+stockOpnameColumns+` FROM stock_opname h WHERE h.id_opname = $1`, idOpname))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return header, nil, apperror.NotFound("stock opname not found")
+		}
+		return header, nil, err
+	}
+
+	details, err := listStockOpnameDetail(s.db, idOpname)
+	if err != nil {
+		return header, nil, err
+	}
+
+	return header, details, nil
+}
+
+// RecordCounts stores counted quantities. Counting the same barang again
+// overwrites the earlier count.
+func (s *stockOpnameRepository) RecordCounts(idOpname string, counts []entity.StockOpnameCount) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockOpname(tx, idOpname); err != nil {
+		return err
+	}
+
+	for _, count := range counts {
+		res, err := tx.Exec(`UPDATE stock_opname_detail SET qty_hitung = $3 WHERE id_opname = $1 AND id_barang = $2`, idOpname, count.IDBarang, count.QtyHitung)
+		if err != nil {
+			return err
+		}
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return apperror.Validation("barang %s tidak termasuk dalam stock opname %s", count.IDBarang, idOpname)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ApproveStockOpname posts one adjustment per barang with a variance, all in
+// a single transaction. The adjustment is counted minus the snapshot, so sales
+// and receipts booked while the count was running are kept.
+func (s *stockOpnameRepository) ApproveStockOpname(idOpname string, kodeAlasan string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockOpname(tx, idOpname); err != nil {
+		return err
+	}
+
+	details, err := listStockOpnameDetail(tx, idOpname)
+	if err != nil {
+		return err
+	}
+
+	movements, shortages, err := opnameAdjustments(idOpname, kodeAlasan, details)
+	if err != nil {
+		return err
+	}
+
+	if len(shortages) > 0 {
+		if err := reserveStock(tx, shortages); err != nil {
+			return err
+		}
+	}
+
+	for _, movement := range movements {
+		if _, err := applyStockMovement(tx, movement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE stock_opname SET status = $2, kode_alasan = $3, approved_at = NOW() WHERE id_opname = $1`, idOpname, entity.OpnameApproved, kodeAlasan)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// opnameAdjustments turns the variances of a fully counted opname into stock
+// adjustments, and returns the qty each short barang has to give out. An item
+// that was not counted yet fails the approval.
+func opnameAdjustments(idOpname string, kodeAlasan string, details []entity.StockOpnameDetail) ([]entity.StockMovement, map[string]int, error) {
+	var movements []entity.StockMovement
+	shortages := make(map[string]int)
+	for _, detail := range details {
+		if detail.QtyHitung == nil {
+			return nil, nil, apperror.Conflict("barang %s belum dihitung", detail.IDBarang)
+		}
+		if detail.Selisih == 0 {
+			continue
+		}
+		if detail.Selisih < 0 {
+			shortages[detail.IDBarang] = -detail.Selisih
+		}
+		movements = append(movements, entity.StockMovement{
+			IDBarang:   detail.IDBarang,
+			Tipe:       entity.MovementAdjustment,
+			Qty:        detail.Selisih,
+			RefID:      idOpname,
+			Keterangan: "stock opname: " + kodeAlasan,
+		})
+	}
+	return movements, shortages, nil
+}
+
+func (s *stockOpnameRepository) CancelStockOpname(idOpname string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockOpname(tx, idOpname); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE stock_opname SET status = $2 WHERE id_opname = $1`, idOpname, entity.OpnameCancelled); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func lockOpenStockOpname(tx *sql.Tx, idOpname string) error {
+	var status string
+	err := tx.QueryRow(`SELECT status FROM stock_opname WHERE id_opname = $1 FOR UPDATE`, idOpname).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.NotFound("stock opname not found")
+		}
+		return err
+	}
+	if status != entity.OpnameOpen {
+		return apperror.Conflict("stock opname %s sudah %s", idOpname, status)
+	}
+	return nil
+}
+
+func listStockOpnameDetail(q queryer, idOpname string) ([]entity.StockOpnameDetail, error) {
+	var details []entity.StockOpnameDetail
+
+	query := `SELECT id_opname_detail, id_opname, id_barang, qty_sistem, qty_hitung, harga FROM stock_opname_detail WHERE id_opname = $1 ORDER BY id_barang`
+	rows, err := q.Query(query, idOpname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			detail    entity.StockOpnameDetail
+			qtyHitung sql.NullInt64
+		)
+		err := rows.Scan(&detail.IDOpnameDetail, &detail.IDOpname, &detail.IDBarang, &detail.QtySistem, &qtyHitung, &detail.Harga)
+		if err != nil {
+			return nil, err
+		}
+		if qtyHitung.Valid {
+			counted := int(qtyHitung.Int64)
+			detail.QtyHitung = &counted
+			detail.Selisih = counted - detail.QtySistem
+			detail.NilaiSelisih = detail.Harga.Mul(detail.Selisih)
+		}
+		details = append(details, detail)
+	}
+
+	return details, rows.Err()
+}
+
+func NewStockOpnameRepository(db *sql.DB) StockOpnameRepository {
+	return &stockOpnameRepository{db: db}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"maps"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"testing"
+)
+
+func TestOpnameAdjustments(t *testing.T) {
+	counted := func(idBarang string, sistem, hitung int) entity.StockOpnameDetail {
+		return entity.StockOpnameDetail{IDBarang: idBarang, QtySistem: sistem, QtyHitung: &hitung, Selisih: hitung - sistem}
+	}
+
+	tests := []struct {
+		name      string
+		details   []entity.StockOpnameDetail
+		qty       map[string]int // qty of each adjustment
+		shortages map[string]int
+	}{
+		{"no variance", []entity.StockOpnameDetail{counted("BR-1", 10, 10)}, map[string]int{}, map[string]int{}},
+		{"surplus", []entity.StockOpnameDetail{counted("BR-1", 10, 12)}, map[string]int{"BR-1": 2}, map[string]int{}},
+		{"shortage", []entity.StockOpnameDetail{counted("BR-1", 10, 7)}, map[string]int{"BR-1": -3}, map[string]int{"BR-1": 3}},
+		{"counted none", []entity.StockOpnameDetail{counted("BR-1", 4, 0)}, map[string]int{"BR-1": -4}, map[string]int{"BR-1": 4}},
+		{"negative system qty", []entity.StockOpnameDetail{counted("BR-1", -2, 0)}, map[string]int{"BR-1": 2}, map[string]int{}},
+		{
+			name:      "mixed",
+			details:   []entity.StockOpnameDetail{counted("BR-1", 10, 7), counted("BR-2", 5, 5), counted("BR-3", 0, 6)},
+			qty:       map[string]int{"BR-1": -3, "BR-3": 6},
+			shortages: map[string]int{"BR-1": 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movements, shortages, err := opnameAdjustments("OP-1", entity.OpnameReasonDamaged, tt.details)
+			if err != nil {
+				t.Fatalf("opnameAdjustments returned error %v", err)
+			}
+			qty := map[string]int{}
+			for _, movement := range movements {
+				qty[movement.IDBarang] = movement.Qty
+				if movement.Tipe != entity.MovementAdjustment || movement.RefID != "OP-1" || movement.Keterangan != "stock opname: damaged" {
+					t.Errorf("movement = %+v, want an adjustment for OP-1 with its reason", movement)
+				}
+			}
+			if !maps.Equal(qty, tt.qty) {
+				t.Errorf("adjustments = %v, want %v", qty, tt.qty)
+			}
+			if !maps.Equal(shortages, tt.shortages) {
+				t.Errorf("shortages = %v, want %v", shortages, tt.shortages)
+			}
+		})
+	}
+}
+
+func TestOpnameAdjustmentsUncounted(t *testing.T) {
+	hitung := 10
+	details := []entity.StockOpnameDetail{
+		{IDBarang: "BR-1", QtySistem: 10, QtyHitung: &hitung},
+		{IDBarang: "BR-2", QtySistem: 5},
+	}
+	movements, _, err := opnameAdjustments("OP-1", entity.OpnameReasonCount, details)
+	if !apperror.Is(err, apperror.CodeConflict) {
+		t.Errorf("error = %v, want a conflict", err)
+	}
+	if len(movements) != 0 {
+		t.Errorf("movements = %v, want none", movements)
+	}
+}
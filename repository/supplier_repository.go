@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"database/sql"
+	"roxy/entity"
+	"roxy/shared/apperror"
+)
+
+type SupplierRepository interface {
+	Create(supplier entity.Supplier) (entity.Supplier, error)
+	List() ([]entity.Supplier, error)
+	GetByID(id string) (entity.Supplier, error)
+	Update(supplier entity.Supplier) (entity.Supplier, error)
+	Delete(id string) error
+}
+
+type supplierRepository struct {
+	db *sql.DB
+}
+
+func (s *supplierRepository) Create(supplier entity.Supplier) (entity.Supplier, error) {
+	err := s.db.QueryRow(`INSERT INTO supplier (nm_supplier, alamat, telepon) VALUES ($1, $2, $3) RETURNING id_supplier`, supplier.NmSupplier, supplier.Alamat, supplier.Telepon).Scan(&supplier.IDSupplier)
+	if err != nil {
+		return entity.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (s *supplierRepository) List() ([]entity.Supplier, error) {
+	var suppliers []entity.Supplier
+
+	rows, err := s.db.Query(`SELECT id_supplier, nm_supplier, alamat, telepon FROM supplier ORDER BY id_supplier`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var supplier entity.Supplier
+		err := rows.Scan(&supplier.IDSupplier, &supplier.NmSupplier, &supplier.Alamat, &supplier.Telepon)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+	return suppliers, rows.Err()
+}
+
+func (s *supplierRepository) GetByID(id string) (entity.Supplier, error) {
+	var supplier entity.Supplier
+
+	err := s.db.QueryRow(`SELECT id_supplier, nm_supplier, alamat, telepon FROM supplier WHERE id_supplier = $1`, id).Scan(&supplier.IDSupplier, &supplier.NmSupplier, &supplier.Alamat, &supplier.Telepon)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.Supplier{}, apperror.NotFound("supplier with ID %s not found", id)
+		}
+		return entity.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (s *supplierRepository) Update(supplier entity.Supplier) (entity.Supplier, error) {
+	_, err := s.db.Exec(`UPDATE supplier SET nm_supplier = $2, alamat = $3, telepon = $4 WHERE id_supplier = $1`, supplier.IDSupplier, supplier.NmSupplier, supplier.Alamat, supplier.Telepon)
+	if err != nil {
+		return entity.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (s *supplierRepository) Delete(id string) error {
+	_, err := s.db.Exec(`DELETE FROM supplier WHERE id_supplier = $1`, id)
+	return err
+}
+
+func NewSupplierRepository(db *sql.DB) SupplierRepository {
+	return &supplierRepository{db: db}
+}
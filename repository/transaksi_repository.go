@@ -4,33 +4,54 @@ import (
 	"database/sql"
 	"fmt"
 	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"slices"
+	"sort"
+	"strings"
 )
 
 type TransaksiRepository interface {
-	CreateTransaksiWithDetail(header entity.TransaksiHeader, details []entity.TransaksiDetail) (string, error)
-	GetAllTransaksi() ([]entity.TransaksiHeader, error)
+	CreateTransaksiWithDetail(actor entity.User, header entity.TransaksiHeader, details []entity.TransaksiDetail, payments []entity.Payment) (string, error)
+	GetAllTransaksi(filter entity.TransaksiFilter) ([]entity.TransaksiHeader, int, error)
 	GetTransaksiByID(idTrans string) (entity.TransaksiHeader, []entity.TransaksiDetail, error)
-	DeleteTransaksi(idTrans string) error
-	UpdateTransaksiWithDetail(transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error)
+	GetTransaksiSummary() (entity.TransaksiSummary, error)
+	VoidTransaksi(actor entity.User, idTrans string, reason string) (entity.TransaksiHeader, error)
+	DeleteTransaksi(actor entity.User, idTrans string) error
+	UpdateTransaksiWithDetail(actor entity.User, transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error)
+	RecalcTotals(actor entity.User, header entity.TransaksiHeader, details []entity.TransaksiDetail, apply bool) (entity.TransaksiHeader, entity.TransaksiHeader, error)
 }
 
 type transaksiRepository struct {
 	DB *sql.DB
 }
 
-func (t *transaksiRepository) CreateTransaksiWithDetail(header entity.TransaksiHeader, details []entity.TransaksiDetail) (string, error) {
+func (t *transaksiRepository) CreateTransaksiWithDetail(actor entity.User, header entity.TransaksiHeader, details []entity.TransaksiDetail, payments []entity.Payment) (string, error) {
 	tx, err := t.DB.Begin()
 	if err != nil {
 		return "", err
 	}
 	defer tx.Rollback()
 
+	requested := make(map[string]int, len(details))
+	for _, detail := range details {
+		requested[detail.IDBarang] += detail.Qty
+	}
+	if err := reserveStock(tx, requested); err != nil {
+		return "", err
+	}
+
+	idShift, err := lockOpenShift(tx, actor.Username)
+	if err != nil {
+		return "", err
+	}
+
 	var idTransaksi string
 	queryHeader := `
-        INSERT INTO transaksi_header (tgl_trans, total)
-        VALUES ($1, $2) RETURNING id_trans
+        INSERT INTO transaksi_header (tgl_trans, diskon, pajak, total, id_shift)
+        VALUES ($1, $2, $3, $4, $5) RETURNING id_trans
     `
-	err = tx.QueryRow(queryHeader, header.TglTrans, header.Total).Scan(&idTransaksi)
+	err = tx.QueryRow(queryHeader, header.TglTrans, header.Diskon, header.Pajak, header.Total, idShift).Scan(&idTransaksi)
 	if err != nil {
 		return "", err
 	}
@@ -40,16 +61,42 @@ func (t *transaksiRepository) CreateTransaksiWithDetail(header entity.TransaksiH
 	}
 
 	for _, detail := range details {
+		movement, err := applyStockMovement(tx, entity.StockMovement{
+			IDBarang: detail.IDBarang,
+			Tipe:     entity.MovementSale,
+			Qty:      -detail.Qty,
+			RefID:    idTransaksi,
+		})
+		if err != nil {
+			return "", err
+		}
+		// cost of goods sold is fixed at the moment of sale
+		detail.Hpp = -movement.Nilai
+
 		queryDetail := `
-            INSERT INTO transaksi_detail (id_trans, id_barang, qty, harga, subtotal)
-            VALUES ($1, $2, $3, $4, $5)
+            INSERT INTO transaksi_detail (id_trans, id_barang, qty, harga, subtotal, hpp)
+            VALUES ($1, $2, $3, $4, $5, $6)
         `
-		_, err := tx.Exec(queryDetail, detail.IDTrans, detail.IDBarang, detail.Qty, detail.Harga, detail.Subtotal)
+		_, err = tx.Exec(queryDetail, detail.IDTrans, detail.IDBarang, detail.Qty, detail.Harga, detail.Subtotal, detail.Hpp)
 		if err != nil {
 			return "", err
 		}
 	}
 
+	if len(payments) > 0 {
+		if _, err := insertPayments(tx, idTransaksi, idShift, header.Total, payments); err != nil {
+			return "", err
+		}
+	}
+
+	created, err := transaksiSnapshot(tx, idTransaksi)
+	if err != nil {
+		return "", err
+	}
+	if err := writeAudit(tx, actor, entity.AuditCreate, entity.AuditTransaksi, idTransaksi, nil, created); err != nil {
+		return "", err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return "", err
 	}
@@ -57,89 +104,246 @@ func (t *transaksiRepository) CreateTransaksiWithDetail(header entity.TransaksiH
 	return idTransaksi, nil
 }
 
-func (t *transaksiRepository) GetAllTransaksi() ([]entity.TransaksiHeader, error) {
+// GetAllTransaksi returns one page of the transaksi matching the filter along
+// with the number of matching rows across all pages.
+func (t *transaksiRepository) GetAllTransaksi(filter entity.TransaksiFilter) ([]entity.TransaksiHeader, int, error) {
 	var transaksis []entity.TransaksiHeader
 
-	query := `SELECT id_trans, tgl_trans, total FROM transaksi_header`
+	if !slices.Contains(entity.TransaksiSortColumns, filter.SortBy) {
+		return nil, 0, fmt.Errorf("unknown sort column %q", filter.SortBy)
+	}
 
-	rows, err := t.DB.Query(query)
+	var args []any
+	var conditions []string
+	addCondition := func(format string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	if !filter.From.IsZero() {
+		addCondition("tgl_trans >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("tgl_trans < $%d", filter.To)
+	}
+	if filter.MinTotal != nil {
+		addCondition("total >= $%d", *filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		addCondition("total <= $%d", *filter.MaxTotal)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := t.DB.QueryRow(`SELECT COUNT(*) FROM transaksi_header`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+	query := `SELECT ` + transaksiHeaderColumns + ` FROM transaksi_header` + where +
+		` ORDER BY ` + filter.SortBy + ` ` + direction + `, id_trans ` + direction +
+		fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := t.DB.Query(query, append(args, filter.Limit, filter.Offset())...)
 	if err != nil {
-		return transaksis, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var transaksi entity.TransaksiHeader
-		err := rows.Scan(&transaksi.IDTrans, &transaksi.TglTrans, &transaksi.Total)
+		transaksi, err := scanTransaksiHeader(rows)
 		if err != nil {
-			return transaksis, err
+			return nil, 0, err
 		}
 		transaksis = append(transaksis, transaksi)
 	}
 
-	return transaksis, nil
+	return transaksis, total, rows.Err()
 }
 
 func (t *transaksiRepository) GetTransaksiByID(idTrans string) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
-	var transaksi entity.TransaksiHeader
 	var details []entity.TransaksiDetail
 
-	queryTransaksi := `SELECT id_trans, tgl_trans, total FROM transaksi_header WHERE id_trans = $1`
-	row := t.DB.QueryRow(queryTransaksi, idTrans)
-	err := row.Scan(&transaksi.IDTrans, &transaksi.TglTrans, &transaksi.Total)
+	queryTransaksi := `SELECT ` + transaksiHeaderColumns + ` FROM transaksi_header WHERE id_trans = $1`
+	transaksi, err := scanTransaksiHeader(t.DB.QueryRow(queryTransaksi, idTrans))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return transaksi, details, fmt.Errorf("transaksi not found")
+			return transaksi, details, apperror.NotFound("transaksi not found")
 		}
 		return transaksi, details, err
 	}
 
-	queryDetail := `SELECT id_trans_detail, id_trans, id_barang, qty, harga, subtotal FROM transaksi_detail WHERE id_trans = $1`
-	rows, err := t.DB.Query(queryDetail, idTrans)
+	details, err = listTransaksiDetail(t.DB, idTrans)
 	if err != nil {
 		return transaksi, details, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var detail entity.TransaksiDetail
-		err := rows.Scan(&detail.IDTransDetail, &detail.IDTrans, &detail.IDBarang, &detail.Qty, &detail.Harga, &detail.Subtotal)
-		if err != nil {
-			return transaksi, details, err
-		}
-		details = append(details, detail)
-	}
 
 	return transaksi, details, nil
 }
 
-func (t *transaksiRepository) UpdateTransaksiWithDetail(transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
+// UpdateTransaksiWithDetail reconciles the stored lines with details: lines
+// carrying an id_trans_detail are updated, lines without one are inserted and
+// stored lines missing from details are removed. The qty difference per barang
+// is booked to the stock ledger in the same transaction, and the hpp of each
+// barang is then spread over its lines by qty.
+func (t *transaksiRepository) UpdateTransaksiWithDetail(actor entity.User, transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
 	tx, err := t.DB.Begin()
 	if err != nil {
 		return transaksi, details, err
 	}
 	defer tx.Rollback()
 
-	// deleteDetail := `DELETE FROM transaksi_detail WHERE id_trans = $1`
-	// _, err = tx.Exec(deleteDetail, transaksi.IDTrans)
-	// if err != nil {
-	// 	return transaksi, details, err
-	// }
+	if err := lockActiveTransaksi(tx, transaksi.IDTrans); err != nil {
+		return transaksi, details, err
+	}
+
+	before, err := transaksiSnapshot(tx, transaksi.IDTrans)
+	if err != nil {
+		return transaksi, details, err
+	}
+
+	returnedLines, err := returnedQty(tx, transaksi.IDTrans)
+	if err != nil {
+		return transaksi, details, err
+	}
+	if len(returnedLines) > 0 {
+		return transaksi, details, apperror.Conflict("transaksi %s sudah memiliki retur dan tidak bisa diubah", transaksi.IDTrans)
+	}
+
+	oldDetails := before.Detail
+
+	existing := make(map[string]entity.TransaksiDetail, len(oldDetails))
+	delta := make(map[string]int)
+	oldQty := make(map[string]int)
+	hpp := make(map[string]money.Money)
+	for _, old := range oldDetails {
+		existing[old.IDTransDetail] = old
+		delta[old.IDBarang] -= old.Qty
+		oldQty[old.IDBarang] += old.Qty
+		hpp[old.IDBarang] += old.Hpp
+	}
+	for _, detail := range details {
+		delta[detail.IDBarang] += detail.Qty
+	}
 
-	updateTransaksi := `UPDATE transaksi_header SET tgl_trans = $1, total = $2 WHERE id_trans = $3`
-	_, err = tx.Exec(updateTransaksi, transaksi.TglTrans, transaksi.Total, transaksi.IDTrans)
+	requested := make(map[string]int)
+	for idBarang, qty := range delta {
+		if qty > 0 {
+			requested[idBarang] = qty
+		}
+	}
+	if len(requested) > 0 {
+		if err := reserveStock(tx, requested); err != nil {
+			return transaksi, details, err
+		}
+	}
+
+	updateTransaksi := `UPDATE transaksi_header SET tgl_trans = $1, diskon = $2, pajak = $3, total = $4 WHERE id_trans = $5`
+	_, err = tx.Exec(updateTransaksi, transaksi.TglTrans, transaksi.Diskon, transaksi.Pajak, transaksi.Total, transaksi.IDTrans)
 	if err != nil {
 		return transaksi, details, err
 	}
 
+	if err := reconcilePayments(tx, transaksi.IDTrans); err != nil {
+		return transaksi, details, err
+	}
+
+	kept := make(map[string]bool, len(details))
+	for i := range details {
+		details[i].IDTrans = transaksi.IDTrans
+
+		if details[i].IDTransDetail == "" {
+			insert := `INSERT INTO transaksi_detail (id_trans, id_barang, qty, harga, subtotal) VALUES ($1, $2, $3, $4, $5) RETURNING id_trans_detail`
+			err = tx.QueryRow(insert, details[i].IDTrans, details[i].IDBarang, details[i].Qty, details[i].Harga, details[i].Subtotal).Scan(&details[i].IDTransDetail)
+			if err != nil {
+				return transaksi, details, err
+			}
+			continue
+		}
+
+		if _, ok := existing[details[i].IDTransDetail]; !ok || kept[details[i].IDTransDetail] {
+			return transaksi, details, apperror.Validation("detail %s bukan bagian dari transaksi %s", details[i].IDTransDetail, transaksi.IDTrans)
+		}
+		kept[details[i].IDTransDetail] = true
+
+		update := `UPDATE transaksi_detail SET id_barang = $1, qty = $2, harga = $3, subtotal = $4 WHERE id_trans_detail = $5`
+		_, err = tx.Exec(update, details[i].IDBarang, details[i].Qty, details[i].Harga, details[i].Subtotal, details[i].IDTransDetail)
+		if err != nil {
+			return transaksi, details, err
+		}
+	}
+
+	for _, old := range oldDetails {
+		if kept[old.IDTransDetail] {
+			continue
+		}
+		_, err = tx.Exec(`DELETE FROM transaksi_detail WHERE id_trans_detail = $1`, old.IDTransDetail)
+		if err != nil {
+			return transaksi, details, err
+		}
+	}
+
+	idBarangs := make([]string, 0, len(delta))
+	for idBarang := range delta {
+		idBarangs = append(idBarangs, idBarang)
+	}
+	sort.Strings(idBarangs)
+
+	for _, idBarang := range idBarangs {
+		if delta[idBarang] == 0 {
+			continue
+		}
+		movement := entity.StockMovement{
+			IDBarang:   idBarang,
+			Tipe:       entity.MovementSale,
+			Qty:        -delta[idBarang],
+			RefID:      transaksi.IDTrans,
+			Keterangan: "koreksi transaksi",
+		}
+		if delta[idBarang] < 0 {
+			// qty taken back goes into stock at what it cost on this transaksi
+			movement.HargaPokok = hpp[idBarang].Div(int64(oldQty[idBarang]))
+		}
+		movement, err = applyStockMovement(tx, movement)
+		if err != nil {
+			return transaksi, details, err
+		}
+		hpp[idBarang] -= movement.Nilai
+	}
+
+	newQty := make(map[string]int)
 	for _, detail := range details {
-		update := `UPDATE transaksi_detail SET id_trans_detail = $1, id_barang = $2, qty = $3, harga = $4, subtotal = $5 WHERE id_trans = $6`
-		_, err = tx.Exec(update, detail.IDTransDetail, detail.IDBarang, detail.Qty, detail.Harga, detail.Subtotal, detail.IDTrans)
+		newQty[detail.IDBarang] += detail.Qty
+	}
+	for i := range details {
+		idBarang := details[i].IDBarang
+		details[i].Hpp = hpp[idBarang].MulRatio(int64(details[i].Qty), int64(newQty[idBarang]))
+		hpp[idBarang] -= details[i].Hpp
+		newQty[idBarang] -= details[i].Qty
+
+		_, err = tx.Exec(`UPDATE transaksi_detail SET hpp = $2 WHERE id_trans_detail = $1`, details[i].IDTransDetail, details[i].Hpp)
 		if err != nil {
 			return transaksi, details, err
 		}
 	}
 
+	after, err := transaksiSnapshot(tx, transaksi.IDTrans)
+	if err != nil {
+		return transaksi, details, err
+	}
+	if err := writeAudit(tx, actor, entity.AuditUpdate, entity.AuditTransaksi, transaksi.IDTrans, before, after); err != nil {
+		return transaksi, details, err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return transaksi, details, err
@@ -148,13 +352,117 @@ func (t *transaksiRepository) UpdateTransaksiWithDetail(transaksi entity.Transak
 	return transaksi, details, nil
 }
 
-func (t *transaksiRepository) DeleteTransaksi(idTrans string) error {
+func (t *transaksiRepository) GetTransaksiSummary() (entity.TransaksiSummary, error) {
+	var summary entity.TransaksiSummary
+
+	query := `SELECT COUNT(*), COALESCE(SUM(total), 0) FROM transaksi_header WHERE status = $1`
+	err := t.DB.QueryRow(query, entity.TransaksiActive).Scan(&summary.JumlahTransaksi, &summary.Total)
+	if err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// VoidTransaksi keeps the transaksi for the record but marks it voided and
+// returns the sold qty of every line to stock in the same DB transaction.
+func (t *transaksiRepository) VoidTransaksi(actor entity.User, idTrans string, reason string) (entity.TransaksiHeader, error) {
+	tx, err := t.DB.Begin()
+	if err != nil {
+		return entity.TransaksiHeader{}, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveTransaksi(tx, idTrans); err != nil {
+		return entity.TransaksiHeader{}, err
+	}
+
+	before, err := transaksiSnapshot(tx, idTrans)
+	if err != nil {
+		return entity.TransaksiHeader{}, err
+	}
+	details := before.Detail
+
+	// qty that came back through a retur is already in stock
+	returnedLines, err := returnedQty(tx, idTrans)
+	if err != nil {
+		return entity.TransaksiHeader{}, err
+	}
+
+	restored := make(map[string]int)
+	restoredHpp := make(map[string]money.Money)
+	var idBarangs []string
+	for _, detail := range details {
+		if _, ok := restored[detail.IDBarang]; !ok {
+			idBarangs = append(idBarangs, detail.IDBarang)
+		}
+		qty := detail.Qty - returnedLines[detail.IDTransDetail]
+		restored[detail.IDBarang] += qty
+		restoredHpp[detail.IDBarang] += detail.Hpp.MulRatio(int64(qty), int64(detail.Qty))
+	}
+	sort.Strings(idBarangs)
+
+	for _, idBarang := range idBarangs {
+		if restored[idBarang] == 0 {
+			continue
+		}
+		_, err = applyStockMovement(tx, entity.StockMovement{
+			IDBarang:   idBarang,
+			Tipe:       entity.MovementVoid,
+			Qty:        restored[idBarang],
+			HargaPokok: restoredHpp[idBarang].Div(int64(restored[idBarang])),
+			RefID:      idTrans,
+			Keterangan: reason,
+		})
+		if err != nil {
+			return entity.TransaksiHeader{}, err
+		}
+	}
+
+	query := `UPDATE transaksi_header SET status = $2, void_reason = $3, voided_at = NOW()
+        WHERE id_trans = $1 RETURNING ` + transaksiHeaderColumns
+	header, err := scanTransaksiHeader(tx.QueryRow(query, idTrans, entity.TransaksiVoided, reason))
+	if err != nil {
+		return entity.TransaksiHeader{}, err
+	}
+
+	after := entity.TransaksiSnapshot{Header: header, Detail: details}
+	if err := writeAudit(tx, actor, entity.AuditVoid, entity.AuditTransaksi, idTrans, before, after); err != nil {
+		return entity.TransaksiHeader{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.TransaksiHeader{}, err
+	}
+
+	return header, nil
+}
+
+func (t *transaksiRepository) DeleteTransaksi(actor entity.User, idTrans string) error {
 	tx, err := t.DB.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	// only voided transaksi can be purged, their stock has already been returned
+	var status string
+	err = tx.QueryRow(`SELECT status FROM transaksi_header WHERE id_trans = $1 FOR UPDATE`, idTrans).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.NotFound("transaksi not found")
+		}
+		return err
+	}
+	if status != entity.TransaksiVoided {
+		return apperror.Conflict("transaksi %s harus di-void sebelum dihapus", idTrans)
+	}
+
+	before, err := transaksiSnapshot(tx, idTrans)
+	if err != nil {
+		return err
+	}
+
 	deleteDetail := `DELETE FROM transaksi_detail WHERE id_trans = $1`
 	_, err = tx.Exec(deleteDetail, idTrans)
 	if err != nil {
@@ -167,6 +475,10 @@ func (t *transaksiRepository) DeleteTransaksi(idTrans string) error {
 		return err
 	}
 
+	if err := writeAudit(tx, actor, entity.AuditDelete, entity.AuditTransaksi, idTrans, before, nil); err != nil {
+		return err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return err
@@ -175,6 +487,142 @@ func (t *transaksiRepository) DeleteTransaksi(idTrans string) error {
 	return nil
 }
 
+// RecalcTotals saves the line subtotals and total worked out for a transaksi,
+// provided its lines, diskon and pajak are still what they were worked out
+// from. It returns the header before and after, and writes nothing unless
+// apply is set.
+func (t *transaksiRepository) RecalcTotals(actor entity.User, header entity.TransaksiHeader, details []entity.TransaksiDetail, apply bool) (entity.TransaksiHeader, entity.TransaksiHeader, error) {
+	tx, err := t.DB.Begin()
+	if err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveTransaksi(tx, header.IDTrans); err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+	before, err := transaksiSnapshot(tx, header.IDTrans)
+	if err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+
+	stored := make(map[string]entity.TransaksiDetail, len(before.Detail))
+	for _, detail := range before.Detail {
+		stored[detail.IDTransDetail] = detail
+	}
+	if len(details) != len(stored) || before.Header.Diskon != header.Diskon || before.Header.Pajak != header.Pajak {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, apperror.Conflict("transaksi %s berubah saat dihitung ulang", header.IDTrans)
+	}
+
+	changed := before.Header.Total != header.Total
+	for _, detail := range details {
+		old, ok := stored[detail.IDTransDetail]
+		if !ok || old.Harga != detail.Harga || old.Qty != detail.Qty {
+			return entity.TransaksiHeader{}, entity.TransaksiHeader{}, apperror.Conflict("transaksi %s berubah saat dihitung ulang", header.IDTrans)
+		}
+		if old.Subtotal == detail.Subtotal {
+			continue
+		}
+		changed = true
+		_, err = tx.Exec(`UPDATE transaksi_detail SET subtotal = $2 WHERE id_trans_detail = $1`, detail.IDTransDetail, detail.Subtotal)
+		if err != nil {
+			return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+		}
+	}
+	if !changed {
+		return before.Header, before.Header, nil
+	}
+
+	_, err = tx.Exec(`UPDATE transaksi_header SET total = $2 WHERE id_trans = $1`, header.IDTrans, header.Total)
+	if err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+	if err := reconcilePayments(tx, header.IDTrans); err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+
+	after, err := transaksiSnapshot(tx, header.IDTrans)
+	if err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+	if !apply {
+		return before.Header, after.Header, nil
+	}
+
+	if err := writeAudit(tx, actor, entity.AuditUpdate, entity.AuditTransaksi, header.IDTrans, before, after); err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return entity.TransaksiHeader{}, entity.TransaksiHeader{}, err
+	}
+	return before.Header, after.Header, nil
+}
+
+const transaksiHeaderColumns = `id_trans, COALESCE(id_shift, ''), tgl_trans, diskon, pajak, total, dibayar, kembalian, status_bayar, status, void_reason, voided_at`
+
+func scanTransaksiHeader(row rowScanner) (entity.TransaksiHeader, error) {
+	var header entity.TransaksiHeader
+	err := row.Scan(&header.IDTrans, &header.IDShift, &header.TglTrans, &header.Diskon, &header.Pajak, &header.Total, &header.Dibayar, &header.Kembalian, &header.StatusBayar, &header.Status, &header.VoidReason, &header.VoidedAt)
+	return header, err
+}
+
+// lockActiveTransaksi locks the header row so concurrent changes to the same
+// transaksi are serialized, and refuses to touch one that was voided.
+func lockActiveTransaksi(tx *sql.Tx, idTrans string) error {
+	var status string
+	err := tx.QueryRow(`SELECT status FROM transaksi_header WHERE id_trans = $1 FOR UPDATE`, idTrans).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperror.NotFound("transaksi not found")
+		}
+		return err
+	}
+	if status == entity.TransaksiVoided {
+		return apperror.Conflict("transaksi %s sudah di-void", idTrans)
+	}
+	return nil
+}
+
+// transaksiSnapshot reads a transaksi inside tx the way it goes into the
+// audit log.
+func transaksiSnapshot(tx *sql.Tx, idTrans string) (entity.TransaksiSnapshot, error) {
+	header, err := scanTransaksiHeader(tx.QueryRow(`SELECT `+transaksiHeaderColumns+` FROM transaksi_header WHERE id_trans = $1`, idTrans))
+	if err != nil {
+		return entity.TransaksiSnapshot{}, err
+	}
+	details, err := listTransaksiDetail(tx, idTrans)
+	if err != nil {
+		return entity.TransaksiSnapshot{}, err
+	}
+	return entity.TransaksiSnapshot{Header: header, Detail: details}, nil
+}
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func listTransaksiDetail(q queryer, idTrans string) ([]entity.TransaksiDetail, error) {
+	var details []entity.TransaksiDetail
+
+	queryDetail := `SELECT id_trans_detail, id_trans, id_barang, qty, harga, subtotal, hpp FROM transaksi_detail WHERE id_trans = $1 ORDER BY id_trans_detail`
+	rows, err := q.Query(queryDetail, idTrans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail entity.TransaksiDetail
+		err := rows.Scan(&detail.IDTransDetail, &detail.IDTrans, &detail.IDBarang, &detail.Qty, &detail.Harga, &detail.Subtotal, &detail.Hpp)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+
+	return details, rows.Err()
+}
+
 func NewTransaksiRepository(db *sql.DB) TransaksiRepository {
 	return &transaksiRepository{DB: db}
 }
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"database/sql"
+	"roxy/entity"
+	"roxy/shared/apperror"
+)
+
+type UserRepository interface {
+	Create(user entity.User) (entity.User, error)
+	List() ([]entity.User, error)
+	Count() (int, error)
+	GetByID(id string) (entity.User, error)
+	GetByUsername(username string) (entity.User, error)
+	Update(user entity.User) (entity.User, error)
+	SaveRefreshToken(token entity.RefreshToken) error
+	// RotateRefreshToken swaps a refresh token for the next one and returns
+	// its user. A token that is unknown, expired or already used fails.
+	RotateRefreshToken(tokenHash string, next entity.RefreshToken) (entity.User, error)
+	RevokeRefreshToken(tokenHash string) error
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+const userColumns = `id_user, username, password_hash, role, aktif, created_at`
+
+func scanUser(row rowScanner) (entity.User, error) {
+	var user entity.User
+	err := row.Scan(&user.IDUser, &user.Username, &user.PasswordHash, &user.Role, &user.Aktif, &user.CreatedAt)
+	return user, err
+}
+
+func (u *userRepository) Create(user entity.User) (entity.User, error) {
+	query := `INSERT INTO app_user (username, password_hash, role, aktif) VALUES ($1, $2, $3, $4) RETURNING ` + userColumns
+	created, err := scanUser(u.db.QueryRow(query, user.Username, user.PasswordHash, user.Role, user.Aktif))
+	if err != nil {
+		return entity.User{}, uniqueViolation(err)
+	}
+	return created, nil
+}
+
+func (u *userRepository) List() ([]entity.User, error) {
+	users := []entity.User{}
+
+	rows, err := u.db.Query(`SELECT ` + userColumns + ` FROM app_user ORDER BY id_user`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (u *userRepository) Count() (int, error) {
+	var count int
+	err := u.db.QueryRow(`SELECT COUNT(*) FROM app_user`).Scan(&count)
+	return count, err
+}
+
+func (u *userRepository) GetByID(id string) (entity.User, error) {
+	user, err := scanUser(u.db.QueryRow(`SELECT `+userColumns+` FROM app_user WHERE id_user = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.User{}, apperror.NotFound("user with ID %s not found", id)
+		}
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+func (u *userRepository) GetByUsername(username string) (entity.User, error) {
+	user, err := scanUser(u.db.QueryRow(`SELECT `+userColumns+` FROM app_user WHERE username = $1`, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.User{}, apperror.NotFound("user %s not found", username)
+		}
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// Update also revokes the refresh tokens of a user that is deactivated or
+// gets a new password, so existing sessions end when the access token does.
+func (u *userRepository) Update(user entity.User) (entity.User, error) {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return entity.User{}, err
+	}
+	defer tx.Rollback()
+
+	old, err := scanUser(tx.QueryRow(`SELECT `+userColumns+` FROM app_user WHERE id_user = $1 FOR UPDATE`, user.IDUser))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.User{}, apperror.NotFound("user with ID %s not found", user.IDUser)
+		}
+		return entity.User{}, err
+	}
+
+	query := `UPDATE app_user SET password_hash = $2, role = $3, aktif = $4 WHERE id_user = $1 RETURNING ` + userColumns
+	updated, err := scanUser(tx.QueryRow(query, user.IDUser, user.PasswordHash, user.Role, user.Aktif))
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if !updated.Aktif || updated.PasswordHash != old.PasswordHash {
+		_, err = tx.Exec(`UPDATE refresh_token SET revoked_at = NOW() WHERE id_user = $1 AND revoked_at IS NULL`, user.IDUser)
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.User{}, err
+	}
+	return updated, nil
+}
+
+func (u *userRepository) SaveRefreshToken(token entity.RefreshToken) error {
+	_, err := u.db.Exec(`INSERT INTO refresh_token (token_hash, id_user, expires_at) VALUES ($1, $2, $3)`, token.TokenHash, token.IDUser, token.ExpiresAt)
+	return err
+}
+
+func (u *userRepository) RotateRefreshToken(tokenHash string, next entity.RefreshToken) (entity.User, error) {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return entity.User{}, err
+	}
+	defer tx.Rollback()
+
+	var idUser string
+	var expired bool
+	err = tx.QueryRow(`SELECT id_user, revoked_at IS NOT NULL OR expires_at <= NOW() FROM refresh_token WHERE token_hash = $1 FOR UPDATE`, tokenHash).Scan(&idUser, &expired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.User{}, apperror.Unauthorized("invalid refresh token")
+		}
+		return entity.User{}, err
+	}
+	if expired {
+		return entity.User{}, apperror.Unauthorized("refresh token expired")
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_token SET revoked_at = NOW() WHERE token_hash = $1`, tokenHash); err != nil {
+		return entity.User{}, err
+	}
+	_, err = tx.Exec(`INSERT INTO refresh_token (token_hash, id_user, expires_at) VALUES ($1, $2, $3)`, next.TokenHash, idUser, next.ExpiresAt)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	user, err := scanUser(tx.QueryRow(`SELECT `+userColumns+` FROM app_user WHERE id_user = $1`, idUser))
+	if err != nil {
+		return entity.User{}, err
+	}
+	if !user.Aktif {
+		return entity.User{}, apperror.Unauthorized("user %s is not active", user.Username)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+func (u *userRepository) RevokeRefreshToken(tokenHash string) error {
+	_, err := u.db.Exec(`UPDATE refresh_token SET revoked_at = NOW() WHERE token_hash = $1 AND revoked_at IS NULL`, tokenHash)
+	return err
+}
+
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{db: db}
+}
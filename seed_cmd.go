@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"roxy/app"
+	"roxy/entity"
+	"roxy/shared/barcode"
+	"roxy/shared/money"
+)
+
+var seedSuppliers = []entity.Supplier{
+	{NmSupplier: "CV Sumber Makmur", Alamat: "Jl. Pasar Baru 12, Bandung", Telepon: "022-4201133"},
+	{NmSupplier: "PT Indo Grosir", Alamat: "Jl. Gatot Subroto 88, Jakarta", Telepon: "021-5550123"},
+}
+
+var seedBarang = []entity.Barang{
+	{Nm_barang: "Beras Pandan Wangi 5kg", Sku: "BRS-PW-5", Kategori: "Sembako", Qty: 40, Harga: money.New(78000), HargaPokok: money.New(69000), ReorderPoint: 10, ReorderQty: 30, LeadTimeHari: 3, SafetyStock: 5},
+	{Nm_barang: "Minyak Goreng 2L", Sku: "MNY-2L", Kategori: "Sembako", Qty: 60, Harga: money.New(36500), HargaPokok: money.New(32000), ReorderPoint: 15, ReorderQty: 48, LeadTimeHari: 2, SafetyStock: 6},
+	{Nm_barang: "Gula Pasir 1kg", Sku: "GLA-1", Kategori: "Sembako", Qty: 80, Harga: money.New(17500), HargaPokok: money.New(15200), ReorderPoint: 20, ReorderQty: 50, LeadTimeHari: 2, SafetyStock: 10},
+	{Nm_barang: "Telur Ayam 1kg", Sku: "TLR-1", Kategori: "Segar", Qty: 25, Harga: money.New(29000), HargaPokok: money.New(26000), ReorderPoint: 10, ReorderQty: 20, LeadTimeHari: 1, SafetyStock: 5},
+	{Nm_barang: "Mie Instan Goreng", Sku: "MIE-GRG", Kategori: "Makanan", Qty: 200, Harga: money.New(3500), HargaPokok: money.New(2800), ReorderPoint: 50, ReorderQty: 120, LeadTimeHari: 3, SafetyStock: 24},
+	{Nm_barang: "Kopi Bubuk 200g", Sku: "KOP-200", Kategori: "Minuman", Qty: 30, Harga: money.New(24000), HargaPokok: money.New(19500), ReorderPoint: 8, ReorderQty: 24, LeadTimeHari: 5, SafetyStock: 4},
+	{Nm_barang: "Teh Celup isi 25", Sku: "TEH-25", Kategori: "Minuman", Qty: 45, Harga: money.New(8500), HargaPokok: money.New(6700), ReorderPoint: 12, ReorderQty: 36, LeadTimeHari: 5, SafetyStock: 6},
+	{Nm_barang: "Sabun Mandi Batang", Sku: "SBN-BTG", Kategori: "Kebersihan", Qty: 70, Harga: money.New(4500), HargaPokok: money.New(3400), ReorderPoint: 20, ReorderQty: 60, LeadTimeHari: 4, SafetyStock: 10},
+}
+
+// runSeed adds sample suppliers and barang for a demo or a development
+// database. What already exists by name is left alone, so it can be run
+// again.
+func runSeed(args []string) error {
+	fs := newFlagSet("seed")
+	fs.Parse(args)
+
+	a, err := app.New()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	suppliers, err := a.SupplierUc.List()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, supplier := range suppliers {
+		existing[supplier.NmSupplier] = true
+	}
+	for _, supplier := range seedSuppliers {
+		if existing[supplier.NmSupplier] {
+			continue
+		}
+		created, err := a.SupplierUc.Create(supplier)
+		if err != nil {
+			return fmt.Errorf("supplier %s: %w", supplier.NmSupplier, err)
+		}
+		fmt.Println("supplier", created.IDSupplier, created.NmSupplier)
+	}
+
+	actor := cliActor()
+	for i, barang := range seedBarang {
+		_, err := a.BarangUc.GetByName(barang.Nm_barang)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		// internal EAN-13 codes start with 2, which GS1 leaves for in-store use
+		body := fmt.Sprintf("2%011d", 1000+i)
+		barang.Barcodes = []string{body + string(barcode.CheckDigit(body))}
+		created, err := a.BarangUc.Create(actor, barang)
+		if err != nil {
+			return fmt.Errorf("barang %s: %w", barang.Nm_barang, err)
+		}
+		fmt.Println("barang", created.Id_barang, created.Nm_barang)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"roxy/app"
+	"roxy/handler"
+	"roxy/migrations"
+	"roxy/shared/migrate"
+)
+
+func runServe(args []string) error {
+	fs := newFlagSet("serve")
+	migrateFirst := fs.Bool("migrate", false, "apply pending migrations before starting, as MIGRATE_ON_START does")
+	fs.Parse(args)
+
+	a, err := app.New()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if *migrateFirst || a.Config.MigrateOnStart {
+		migrator, err := migrate.New(a.DB, migrations.FS)
+		if err != nil {
+			return err
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			return fmt.Errorf("migration failed, %w", err)
+		}
+	}
+
+	// without any user nobody could log in, so do not start at all
+	if err := a.UserUc.EnsureAdmin(a.Config.AdminUsername, a.Config.AdminPassword); err != nil {
+		return fmt.Errorf("first admin not created, %w", err)
+	}
+
+	handler.NewServer(a).Run()
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package apperror holds the domain errors returned by the usecases and
+// repositories. The handlers turn them into HTTP responses in one place, so
+// nothing above the usecase has to guess from the error message.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code is the machine-readable error code sent to clients.
+type Code string
+
+const (
+	CodeValidation        Code = "VALIDATION_ERROR"
+	CodeBadRequest        Code = "BAD_REQUEST"
+	CodeUnauthorized      Code = "UNAUTHORIZED"
+	CodeForbidden         Code = "FORBIDDEN"
+	CodeNotFound          Code = "NOT_FOUND"
+	CodeConflict          Code = "CONFLICT"
+	CodeInsufficientStock Code = "INSUFFICIENT_STOCK"
+	CodeInternal          Code = "INTERNAL_ERROR"
+)
+
+// FieldError points a validation failure at one field of the request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Details any
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status is the HTTP status the error is sent with.
+func (e *Error) Status() int {
+	switch e.Code {
+	case CodeValidation, CodeBadRequest:
+		return http.StatusBadRequest
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict, CodeInsufficientStock:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func newError(code Code, format string, args []any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validation is a request that breaks a business rule not tied to a single
+// field, see Invalid for those that are.
+func Validation(format string, args ...any) *Error {
+	return newError(CodeValidation, format, args)
+}
+
+// Invalid is a validation failure of one field.
+func Invalid(field string, format string, args ...any) *Error {
+	err := newError(CodeValidation, format, args)
+	err.Fields = []FieldError{{Field: field, Message: err.Message}}
+	return err
+}
+
+func BadRequest(format string, args ...any) *Error {
+	return newError(CodeBadRequest, format, args)
+}
+
+func Unauthorized(format string, args ...any) *Error {
+	return newError(CodeUnauthorized, format, args)
+}
+
+func Forbidden(format string, args ...any) *Error {
+	return newError(CodeForbidden, format, args)
+}
+
+func NotFound(format string, args ...any) *Error {
+	return newError(CodeNotFound, format, args)
+}
+
+// Conflict is a request that clashes with the current state, such as a
+// duplicate name or a document that has already been closed.
+func Conflict(format string, args ...any) *Error {
+	return newError(CodeConflict, format, args)
+}
+
+// Internal wraps an unexpected error. Its message is not shown to clients.
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// Is reports whether err is, or wraps, an Error with the given code.
+func Is(err error, code Code) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Code == code
+}
+
+// CodeForStatus picks the code for errors that only come with an HTTP status.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	default:
+		return CodeInternal
+	}
+}
+
+// FieldErrors collects validation failures so that a request is rejected
+// with all of them at once instead of one per round trip.
+type FieldErrors []FieldError
+
+func (f *FieldErrors) Add(field string, format string, args ...any) {
+	*f = append(*f, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err is nil when nothing was collected.
+func (f FieldErrors) Err() error {
+	switch len(f) {
+	case 0:
+		return nil
+	case 1:
+		return &Error{Code: CodeValidation, Message: f[0].Message, Fields: f}
+	default:
+		return &Error{Code: CodeValidation, Message: fmt.Sprintf("%d fields are invalid", len(f)), Fields: f}
+	}
+}
@@ -0,0 +1,53 @@
+// Package barcode validates the codes printed on barang: EAN-13, EAN-8,
+// UPC-A and internal codes, all of which end in a GS1 mod 10 check digit.
+package barcode
+
+import (
+	"roxy/shared/apperror"
+	"strings"
+)
+
+const (
+	minLength = 6
+	maxLength = 20
+)
+
+// Normalize validates code and returns the form it is stored and looked up
+// under. A UPC-A code is the same item as the EAN-13 with a leading zero, so it
+// is widened to EAN-13 and a scanner reporting either finds the barang. A bad
+// code is reported as a validation error on the barcode field.
+func Normalize(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < minLength || len(code) > maxLength {
+		return "", apperror.Invalid("barcode", "barcode %q must be %d to %d digits", code, minLength, maxLength)
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return "", apperror.Invalid("barcode", "barcode %q must only contain digits", code)
+		}
+	}
+	if CheckDigit(code[:len(code)-1]) != code[len(code)-1] {
+		return "", apperror.Invalid("barcode", "barcode %q has an invalid check digit", code)
+	}
+
+	if len(code) == 12 {
+		code = "0" + code
+	}
+	return code, nil
+}
+
+// CheckDigit computes the GS1 mod 10 check digit for the digits in body:
+// from the right, digits are weighted 3, 1, 3, 1 and so on, and the check
+// digit brings the sum up to a multiple of 10. Internal codes can be issued by
+// appending it to any run of digits.
+func CheckDigit(body string) byte {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		digit := int(body[len(body)-1-i] - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
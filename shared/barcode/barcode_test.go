@@ -0,0 +1,81 @@
+package barcode
+
+import (
+	"roxy/shared/apperror"
+	"testing"
+)
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		body string
+		want byte
+	}{
+		{"400638133393", '1'}, // EAN-13
+		{"978030640615", '7'}, // EAN-13 of an ISBN
+		{"590123412345", '7'}, // EAN-13
+		{"9638507", '4'},      // EAN-8
+		{"7351353", '7'},      // EAN-8
+		{"03600029145", '2'},  // UPC-A
+		{"003600029145", '2'}, // the UPC-A as EAN-13 keeps its check digit
+		{"00000", '0'},
+		{"", '0'},
+	}
+	for _, tt := range tests {
+		if got := CheckDigit(tt.body); got != tt.want {
+			t.Errorf("CheckDigit(%q) = %c, want %c", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"4006381333931", "4006381333931"},   // EAN-13
+		{"9780306406157", "9780306406157"},   // EAN-13
+		{" 5901234123457 ", "5901234123457"}, // scanner padding
+		{"96385074", "96385074"},             // EAN-8
+		{"73513537", "73513537"},             // EAN-8
+		{"036000291452", "0036000291452"},    // UPC-A widened to EAN-13
+		{"0036000291452", "0036000291452"},   // the same item as EAN-13
+		{"012345678905", "0012345678905"},    // UPC-A
+		{"2000000010007", "2000000010007"},   // internal code
+		{"100007", "100007"},                 // shortest internal code
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.code)
+		if err != nil {
+			t.Errorf("Normalize(%q) returned error %v", tt.code, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	tests := []string{
+		"4006381333932",         // EAN-13, wrong check digit
+		"9780306406150",         // EAN-13, wrong check digit
+		"96385075",              // EAN-8, wrong check digit
+		"036000291453",          // UPC-A, wrong check digit
+		"4006381333913",         // EAN-13, two digits swapped
+		"",                      // empty
+		"12345",                 // too short
+		"123456789012345678901", // too long
+		"40063813339X1",         // not a digit
+		"4006-381333931",        // not a digit
+	}
+	for _, code := range tests {
+		got, err := Normalize(code)
+		if err == nil {
+			t.Errorf("Normalize(%q) = %q, want an error", code, got)
+			continue
+		}
+		if !apperror.Is(err, apperror.CodeValidation) {
+			t.Errorf("Normalize(%q) error %v is not a validation error", code, err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package common
+
+import (
+	"encoding/csv"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SendCSVResponse writes records as a CSV attachment, the first record being
+// the header row.
+func SendCSVResponse(ctx *gin.Context, filename string, records [][]string) {
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Status(http.StatusOK)
+
+	writer := csv.NewWriter(ctx.Writer)
+	if err := writer.WriteAll(records); err != nil {
+		ctx.Error(err)
+	}
+}
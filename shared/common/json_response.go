@@ -2,13 +2,14 @@ package common
 
 import (
 	"net/http"
+	"roxy/shared/apperror"
 	"roxy/shared/model"
 
 	"github.com/gin-gonic/gin"
 )
 
 func SendSingleResponseCreated(ctx *gin.Context, data interface{}, message string) {
-	ctx.JSON(http.StatusOK, &model.SingleResponse{
+	ctx.JSON(http.StatusCreated, &model.SingleResponse{
 		Status: model.Status{
 			Code:    http.StatusCreated,
 			Message: message,
@@ -27,9 +28,32 @@ func SendSingleResponseOk(ctx *gin.Context, data interface{}, message string) {
 	})
 }
 
+func SendPagedResponseOk(ctx *gin.Context, data interface{}, paging model.Paging, message string) {
+	ctx.JSON(http.StatusOK, &model.PagedResponse{
+		Status: model.Status{
+			Code:    http.StatusOK,
+			Message: message,
+		},
+		Data:   data,
+		Paging: paging,
+	})
+}
+
 func SendErrorResponse(ctx *gin.Context, code int, message string) {
-	ctx.AbortWithStatusJSON(code, &model.Status{
-		Code:    code,
-		Message: message,
+	SendAppError(ctx, &apperror.Error{Code: apperror.CodeForStatus(code), Message: message})
+}
+
+func SendAppError(ctx *gin.Context, err *apperror.Error) {
+	status := err.Status()
+	ctx.AbortWithStatusJSON(status, &model.ErrorResponse{
+		Status: model.Status{
+			Code:    status,
+			Message: err.Message,
+		},
+		Error: model.ErrorDetail{
+			Code:    err.Code,
+			Fields:  err.Fields,
+			Details: err.Details,
+		},
 	})
 }
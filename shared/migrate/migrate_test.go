@@ -0,0 +1,421 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeDB stands in for Postgres: it keeps schema_migrations in memory and
+// logs the migration scripts it runs. A script containing "FAIL" fails.
+type fakeDB struct {
+	mu      sync.Mutex
+	applied map[int]fakeRow
+	scripts []string
+}
+
+type fakeRow struct {
+	name     string
+	checksum string
+}
+
+// change is a schema_migrations insert (name set) or delete.
+type change struct {
+	version int
+	row     *fakeRow
+}
+
+func (db *fakeDB) apply(changes []change) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, c := range changes {
+		if c.row == nil {
+			delete(db.applied, c.version)
+		} else {
+			db.applied[c.version] = *c.row
+		}
+	}
+}
+
+type fakeConn struct {
+	db *fakeDB
+	tx *fakeTx
+}
+
+type fakeTx struct {
+	conn    *fakeConn
+	changes []change
+	scripts []string
+}
+
+func (db *fakeDB) Open(string) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.tx = &fakeTx{conn: c}
+	return c.tx, nil
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.conn.db.apply(tx.changes)
+	tx.conn.db.mu.Lock()
+	tx.conn.db.scripts = append(tx.conn.db.scripts, tx.scripts...)
+	tx.conn.db.mu.Unlock()
+	tx.conn.tx = nil
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.conn.tx = nil
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var changes []change
+	switch {
+	case strings.HasPrefix(query, "SELECT pg_advisory"), strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		changes = []change{{version: int(args[0].Value.(int64)), row: &fakeRow{name: args[1].Value.(string), checksum: args[2].Value.(string)}}}
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		changes = []change{{version: int(args[0].Value.(int64))}}
+	default:
+		if strings.Contains(query, "FAIL") {
+			return nil, fmt.Errorf("syntax error in %q", query)
+		}
+		if c.tx == nil {
+			return nil, errors.New("migration script run outside a transaction")
+		}
+		c.tx.scripts = append(c.tx.scripts, query)
+		return driver.RowsAffected(0), nil
+	}
+
+	if c.tx != nil {
+		c.tx.changes = append(c.tx.changes, changes...)
+	} else {
+		c.db.apply(changes)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "SELECT version, checksum, applied_at FROM schema_migrations") {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	rows := &fakeRows{}
+	for version, row := range c.db.applied {
+		rows.values = append(rows.values, []driver.Value{int64(version), row.checksum, time.Date(2024, 12, 7, 0, 0, 0, 0, time.UTC)})
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version", "checksum", "applied_at"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var driverCount int
+
+// openFake opens a fresh fake database with the given rows already applied.
+func openFake(t *testing.T, applied map[int]fakeRow) (*sql.DB, *fakeDB) {
+	t.Helper()
+	if applied == nil {
+		applied = map[int]fakeRow{}
+	}
+	fake := &fakeDB{applied: applied}
+	driverCount++
+	name := fmt.Sprintf("migratefake%d", driverCount)
+	sql.Register(name, fake)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0002_barang.up.sql":   {Data: []byte("CREATE TABLE barang ()")},
+		"0002_barang.down.sql": {Data: []byte("DROP TABLE barang")},
+		"0001_init.up.sql":     {Data: []byte("CREATE TABLE init ()")},
+		"0001_init.down.sql":   {Data: []byte("DROP TABLE init")},
+		"0010_stok.up.sql":     {Data: []byte("CREATE TABLE stok ()")},
+		"README.md":            {Data: []byte("not a migration")},
+		"0003_Bad-Name.up.sql": {Data: []byte("ignored")},
+		"embed.go":             {Data: []byte("package migrations")},
+	}
+}
+
+func versions(migrations []Migration) []int {
+	var out []int
+	for _, migration := range migrations {
+		out = append(out, migration.Version)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	migrator, err := New(nil, testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(migrator.migrations); !slices.Equal(got, []int{1, 2, 10}) {
+		t.Fatalf("versions = %v, want [1 2 10]", got)
+	}
+
+	first := migrator.migrations[0]
+	if first.Name != "init" || first.Up != "CREATE TABLE init ()" || first.Down != "DROP TABLE init" {
+		t.Errorf("0001 = %+v", first)
+	}
+	sum := sha256.Sum256([]byte("CREATE TABLE init ()"))
+	if first.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("checksum = %q, want the sha256 of the up file", first.Checksum)
+	}
+	if migrator.migrations[2].Down != "" {
+		t.Errorf("0010 has down %q, want none", migrator.migrations[2].Down)
+	}
+
+	other, _ := New(nil, fstest.MapFS{"0001_init.up.sql": {Data: []byte("CREATE TABLE init ()")}})
+	if other.migrations[0].Checksum != first.Checksum {
+		t.Error("the same up file gives a different checksum")
+	}
+	edited, _ := New(nil, fstest.MapFS{"0001_init.up.sql": {Data: []byte("CREATE TABLE init (id INT)")}})
+	if edited.migrations[0].Checksum == first.Checksum {
+		t.Error("an edited up file keeps its checksum")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"two names", fstest.MapFS{
+			"0001_init.up.sql":    {Data: []byte("CREATE TABLE a ()")},
+			"0001_other.down.sql": {Data: []byte("DROP TABLE a")},
+		}},
+		{"down without up", fstest.MapFS{
+			"0001_init.up.sql":   {Data: []byte("CREATE TABLE a ()")},
+			"0002_more.down.sql": {Data: []byte("DROP TABLE b")},
+		}},
+	}
+	for _, tt := range tests {
+		if _, err := New(nil, tt.fsys); err == nil {
+			t.Errorf("%s: New returned no error", tt.name)
+		}
+	}
+}
+
+func TestUp(t *testing.T) {
+	fsys := testFS()
+	checksum := func(version int) string {
+		migrator, _ := New(nil, fsys)
+		for _, migration := range migrator.migrations {
+			if migration.Version == version {
+				return migration.Checksum
+			}
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name    string
+		applied map[int]fakeRow
+		fsys    fstest.MapFS
+		done    []int
+		scripts []string
+		after   []int
+		fails   bool
+	}{
+		{
+			name:    "fresh database in version order",
+			done:    []int{1, 2, 10},
+			scripts: []string{"CREATE TABLE init ()", "CREATE TABLE barang ()", "CREATE TABLE stok ()"},
+			after:   []int{1, 2, 10},
+		},
+		{
+			name:    "only what is pending",
+			applied: map[int]fakeRow{1: {"init", checksum(1)}},
+			done:    []int{2, 10},
+			scripts: []string{"CREATE TABLE barang ()", "CREATE TABLE stok ()"},
+			after:   []int{1, 2, 10},
+		},
+		{
+			name:    "up to date",
+			applied: map[int]fakeRow{1: {"init", checksum(1)}, 2: {"barang", checksum(2)}, 10: {"stok", checksum(10)}},
+			after:   []int{1, 2, 10},
+		},
+		{
+			name:    "edited after it was applied",
+			applied: map[int]fakeRow{1: {"init", strings.Repeat("0", 64)}},
+			after:   []int{1},
+			fails:   true,
+		},
+		{
+			name: "a failing migration stops the run and leaves nothing behind",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":  {Data: []byte("CREATE TABLE init ()")},
+				"0002_bad.up.sql":   {Data: []byte("FAIL")},
+				"0003_later.up.sql": {Data: []byte("CREATE TABLE later ()")},
+			},
+			done:    []int{1},
+			scripts: []string{"CREATE TABLE init ()"},
+			after:   []int{1},
+			fails:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := openFake(t, tt.applied)
+			fs := fsys
+			if tt.fsys != nil {
+				fs = tt.fsys
+			}
+			migrator, err := New(db, fs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done, err := migrator.Up(context.Background())
+			if tt.fails != (err != nil) {
+				t.Errorf("Up error = %v, want failure %v", err, tt.fails)
+			}
+			if got := versions(done); !slices.Equal(got, tt.done) {
+				t.Errorf("applied %v, want %v", got, tt.done)
+			}
+			if !slices.Equal(fake.scripts, tt.scripts) {
+				t.Errorf("ran %q, want %q", fake.scripts, tt.scripts)
+			}
+			var after []int
+			for version := range fake.applied {
+				after = append(after, version)
+			}
+			slices.Sort(after)
+			if !slices.Equal(after, tt.after) {
+				t.Errorf("schema_migrations = %v, want %v", after, tt.after)
+			}
+		})
+	}
+}
+
+func TestDown(t *testing.T) {
+	migrator, _ := New(nil, testFS())
+	row := func(version int) fakeRow {
+		for _, migration := range migrator.migrations {
+			if migration.Version == version {
+				return fakeRow{migration.Name, migration.Checksum}
+			}
+		}
+		return fakeRow{}
+	}
+
+	tests := []struct {
+		name    string
+		applied map[int]fakeRow
+		undone  int // 0 when nothing is rolled back
+		scripts []string
+		fails   bool
+	}{
+		{"the last applied", map[int]fakeRow{1: row(1), 2: row(2)}, 2, []string{"DROP TABLE barang"}, false},
+		{"nothing applied", nil, 0, nil, false},
+		{"no down file", map[int]fakeRow{1: row(1), 2: row(2), 10: row(10)}, 0, nil, true},
+		{"edited after it was applied", map[int]fakeRow{1: {"init", strings.Repeat("0", 64)}}, 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := openFake(t, tt.applied)
+			migrator, err := New(db, testFS())
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := len(fake.applied)
+
+			undone, err := migrator.Down(context.Background())
+			if tt.fails != (err != nil) {
+				t.Errorf("Down error = %v, want failure %v", err, tt.fails)
+			}
+			switch {
+			case tt.undone == 0 && undone != nil:
+				t.Errorf("rolled back %04d, want nothing", undone.Version)
+			case tt.undone != 0 && (undone == nil || undone.Version != tt.undone):
+				t.Errorf("rolled back %v, want %04d", undone, tt.undone)
+			}
+			if !slices.Equal(fake.scripts, tt.scripts) {
+				t.Errorf("ran %q, want %q", fake.scripts, tt.scripts)
+			}
+			if _, still := fake.applied[tt.undone]; tt.undone != 0 && still {
+				t.Errorf("%04d is still recorded", tt.undone)
+			}
+			if tt.undone == 0 && len(fake.applied) != before {
+				t.Errorf("schema_migrations changed from %d to %d rows", before, len(fake.applied))
+			}
+		})
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	db, fake := openFake(t, nil)
+	migrator, err := New(db, testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Baseline(context.Background(), 3); err == nil {
+		t.Error("Baseline of an unknown version returned no error")
+	}
+	done, err := migrator.Baseline(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(done); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("recorded %v, want [1 2]", got)
+	}
+	if len(fake.scripts) != 0 {
+		t.Errorf("ran %q, want nothing", fake.scripts)
+	}
+	if _, err := migrator.Baseline(context.Background(), 2); err == nil {
+		t.Error("a second Baseline returned no error")
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied []bool
+	for _, status := range statuses {
+		applied = append(applied, status.Applied)
+		if status.Modified {
+			t.Errorf("%04d is reported modified", status.Version)
+		}
+	}
+	if !slices.Equal(applied, []bool{true, true, false}) {
+		t.Errorf("applied = %v, want [true true false]", applied)
+	}
+}
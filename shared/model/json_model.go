@@ -1,5 +1,7 @@
 package model
 
+import "roxy/shared/apperror"
+
 type Status struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -9,3 +11,43 @@ type SingleResponse struct {
 	Status Status      `json:"status"`
 	Data   interface{} `json:"data"`
 }
+
+type PagedResponse struct {
+	Status Status      `json:"status"`
+	Data   interface{} `json:"data"`
+	Paging Paging      `json:"paging"`
+}
+
+type ErrorResponse struct {
+	Status Status      `json:"status"`
+	Error  ErrorDetail `json:"error"`
+}
+
+// ErrorDetail carries the machine-readable side of an error: its code, the
+// fields that failed validation and anything else the client can act on,
+// such as the items short of stock.
+type ErrorDetail struct {
+	Code    apperror.Code         `json:"code"`
+	Fields  []apperror.FieldError `json:"fields,omitempty"`
+	Details interface{}           `json:"details,omitempty"`
+}
+
+type Paging struct {
+	Page        int `json:"page"`
+	RowsPerPage int `json:"rows_per_page"`
+	TotalRows   int `json:"total_rows"`
+	TotalPages  int `json:"total_pages"`
+}
+
+func NewPaging(page, rowsPerPage, totalRows int) Paging {
+	totalPages := 0
+	if rowsPerPage > 0 {
+		totalPages = (totalRows + rowsPerPage - 1) / rowsPerPage
+	}
+	return Paging{
+		Page:        page,
+		RowsPerPage: rowsPerPage,
+		TotalRows:   totalRows,
+		TotalPages:  totalPages,
+	}
+}
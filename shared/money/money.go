@@ -0,0 +1,184 @@
+// Package money holds rupiah amounts as an integer number of sen (1/100
+// rupiah) so sums never drift the way float32/float64 did.
+//
+// Rounding: harga × qty is always exact. Whenever a calculation produces a
+// fraction of a sen (percentages, averages, unit costs) it is rounded half
+// away from zero to the nearest sen on each line, and an invoice total is the
+// sum of those already rounded lines.
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Money int64
+
+// Scale is the number of sen in one rupiah, matching NUMERIC(15,2).
+const Scale = 100
+
+func New(rupiah int64) Money {
+	return Money(rupiah * Scale)
+}
+
+// Parse reads a decimal string such as "15000", "-2500.5" or "1999.99".
+// Digits beyond the second decimal are rounded half away from zero.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("money: empty amount")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("money: invalid amount %q: %w", s, err)
+	}
+
+	var sen int64
+	for i := 0; i < 2; i++ {
+		sen *= 10
+		if i < len(frac) {
+			sen += int64(frac[i] - '0')
+		}
+	}
+	if len(frac) > 2 && frac[2] >= '5' {
+		sen++
+	}
+
+	m := Money(units*Scale + sen)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/Scale, v%Scale)
+}
+
+// Mul returns m × qty, which is always exact.
+func (m Money) Mul(qty int) Money {
+	return m * Money(qty)
+}
+
+// MulRatio returns m × num / den rounded half away from zero to the sen.
+func (m Money) MulRatio(num, den int64) Money {
+	return Money(divRound(int64(m)*num, den))
+}
+
+// Div returns m / n rounded half away from zero to the sen.
+func (m Money) Div(n int64) Money {
+	return Money(divRound(int64(m), n))
+}
+
+func divRound(num, den int64) int64 {
+	if den == 0 {
+		return 0
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	q, r := num/den, num%den
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= den {
+		if num < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// MarshalJSON writes the amount as a plain JSON number with two decimals.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string, without
+// going through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case int64:
+		*m = New(v)
+	case float64:
+		parsed, err := Parse(strconv.FormatFloat(v, 'f', -1, 64))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,204 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{"0", 0},
+		{"15000", 1500000},
+		{"1999.99", 199999},
+		{"-2500.5", -250050},
+		{"+12", 1200},
+		{".5", 50},
+		{"7.", 700},
+		{" 42.10 ", 4210},
+		{"0.004", 0},
+		{"0.005", 1},
+		{"0.0049999", 0},
+		{"1.995", 200},
+		{"-0.005", -1},
+		{"-0.004", 0},
+		{"-1.995", -200},
+		{"12.3456", 1235},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{"", " ", "-", ".", "abc", "1,5", "1.2.3", "--1", "1e3", "99999999999999999999"} {
+		if got, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "0.00"},
+		{1, "0.01"},
+		{-1, "-0.01"},
+		{1500000, "15000.00"},
+		{-250050, "-2500.50"},
+		{199999, "1999.99"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		if back, err := Parse(tt.want); err != nil || back != tt.in {
+			t.Errorf("Parse(%q) = %d, %v, want %d", tt.want, back, err, tt.in)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		m    Money
+		qty  int
+		want Money
+	}{
+		{New(3500), 3, New(10500)},
+		{199999, 7, 1399993},
+		{-250, 4, -1000},
+		{1500, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Mul(tt.qty); got != tt.want {
+			t.Errorf("Money(%d).Mul(%d) = %d, want %d", tt.m, tt.qty, got, tt.want)
+		}
+	}
+}
+
+func TestMulRatio(t *testing.T) {
+	tests := []struct {
+		m        Money
+		num, den int64
+		want     Money
+	}{
+		// 11% pajak
+		{New(10000), 11, 100, New(1100)},
+		{1005, 11, 100, 111},   // 110.55 sen
+		{1050, 11, 100, 116},   // 115.5 sen, half rounds up
+		{1045, 11, 100, 115},   // 114.95 sen
+		{-1050, 11, 100, -116}, // half rounds away from zero
+		{1050, -11, 100, -116}, // sign from the ratio
+		{1050, 11, -100, -116}, // and from the denominator
+		{100, 1, 3, 33},        // 33.33 sen
+		{200, 1, 3, 67},        // 66.67 sen
+		{5, 1, 2, 3},           // 2.5 sen
+		{-5, 1, 2, -3},         // -2.5 sen
+		{12345, 0, 100, 0},     // nothing
+		{12345, 1, 0, 0},       // division by zero gives 0
+	}
+	for _, tt := range tests {
+		if got := tt.m.MulRatio(tt.num, tt.den); got != tt.want {
+			t.Errorf("Money(%d).MulRatio(%d, %d) = %d, want %d", tt.m, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	tests := []struct {
+		m    Money
+		n    int64
+		want Money
+	}{
+		{New(10000), 4, New(2500)},
+		{100, 3, 33},
+		{200, 3, 67},
+		{5, 2, 3},
+		{-5, 2, -3},
+		{5, -2, -3},
+		{-5, -2, 3},
+		{7, 2, 4},
+		{-7, 2, -4},
+		{1, 3, 0},
+		{-1, 3, 0},
+		{100, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Div(tt.n); got != tt.want {
+			t.Errorf("Money(%d).Div(%d) = %d, want %d", tt.m, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  any
+		want Money
+	}{
+		{nil, 0},
+		{[]byte("1999.99"), 199999},
+		{[]byte("-2500.50"), -250050},
+		{[]byte("0.005"), 1},
+		{"15000.00", 1500000},
+		{int64(15000), 1500000},
+		{int64(-3), -300},
+		{float64(1999.99), 199999},
+		{float64(-2500.5), -250050},
+		{float64(0.1) + float64(0.2), 30}, // 0.30000000000000004
+		{float64(0.005), 1},
+		{float64(-0.005), -1},
+	}
+	for _, tt := range tests {
+		m := Money(12345)
+		if err := m.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%#v) returned error %v", tt.src, err)
+			continue
+		}
+		if m != tt.want {
+			t.Errorf("Scan(%#v) = %d, want %d", tt.src, m, tt.want)
+		}
+	}
+}
+
+func TestScanInvalid(t *testing.T) {
+	for _, src := range []any{[]byte("abc"), "", true, float64(1e30)} {
+		var m Money
+		if err := m.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) = %d, want an error", src, m)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Harga  Money `json:"harga"`
+		Diskon Money `json:"diskon"`
+		Pajak  Money `json:"pajak"`
+	}
+	v.Pajak = 99
+	if err := json.Unmarshal([]byte(`{"harga": 1999.995, "diskon": "-2.5", "pajak": null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Harga != 200000 || v.Diskon != -250 || v.Pajak != 99 {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"harga":2000.00,"diskon":-2.50,"pajak":0.99}`; string(out) != want {
+		t.Errorf("Marshal = %s, want %s", out, want)
+	}
+}
@@ -0,0 +1,91 @@
+// Package notifier delivers stock alerts. Which Notifier is used is picked
+// from config when the server starts.
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"roxy/entity"
+	"strings"
+	"time"
+)
+
+type Notifier interface {
+	Notify(alert entity.StockAlert) error
+}
+
+func message(alert entity.StockAlert) string {
+	return fmt.Sprintf("Stok %s (%s) tinggal %d, di bawah reorder point %d. Saran pemesanan: %d.",
+		alert.NmBarang, alert.IDBarang, alert.Qty, alert.ReorderPoint, alert.ReorderQty)
+}
+
+// LogNotifier writes alerts to the standard logger.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(alert entity.StockAlert) error {
+	log.Printf("[stock alert] %s", message(alert))
+	return nil
+}
+
+func NewLogNotifier() Notifier {
+	return LogNotifier{}
+}
+
+// WebhookNotifier POSTs every alert as JSON to a URL. Any status other than
+// 2xx counts as a failed delivery.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (w *WebhookNotifier) Notify(alert entity.StockAlert) error {
+	body, err := json.Marshal(struct {
+		entity.StockAlert
+		Message string `json:"message"`
+	}{alert, message(alert)})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+func NewWebhookNotifier(url string) Notifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// EmailNotifier mails alerts through an SMTP server without authentication,
+// such as a local relay or a MailHog stand-in during development.
+type EmailNotifier struct {
+	addr string
+	from string
+	to   []string
+}
+
+func (e *EmailNotifier) Notify(alert entity.StockAlert) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&msg, "Subject: Stok menipis: %s\r\n", alert.NmBarang)
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(message(alert) + "\r\n")
+
+	return smtp.SendMail(e.addr, nil, e.from, e.to, []byte(msg.String()))
+}
+
+func NewEmailNotifier(addr string, from string, to []string) Notifier {
+	return &EmailNotifier{addr: addr, from: from, to: to}
+}
@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"slices"
+	"strings"
+)
+
+type AuditUsecase interface {
+	List(filter entity.AuditFilter) ([]entity.AuditLog, int, error)
+}
+
+type auditUsecase struct {
+	auditRepo repository.AuditRepository
+}
+
+func (a *auditUsecase) List(filter entity.AuditFilter) ([]entity.AuditLog, int, error) {
+	if err := normalizePage(&filter.Page); err != nil {
+		return nil, 0, err
+	}
+	if filter.Entity != "" && !slices.Contains(entity.AuditEntities, filter.Entity) {
+		return nil, 0, apperror.Invalid("entity", "entity must be one of %s", strings.Join(entity.AuditEntities, ", "))
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, 0, apperror.Invalid("to", "tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+
+	return a.auditRepo.List(filter)
+}
+
+func NewAuditUsecase(auditRepo repository.AuditRepository) AuditUsecase {
+	return &auditUsecase{auditRepo: auditRepo}
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AuthUsecase interface {
+	Login(username, password string) (entity.AuthToken, error)
+	Refresh(refreshToken string) (entity.AuthToken, error)
+	Logout(refreshToken string) error
+	// Authenticate checks an access token and returns the user it was
+	// issued to, as of the time it was issued.
+	Authenticate(accessToken string) (entity.User, error)
+}
+
+type authUsecase struct {
+	userRepo        repository.UserRepository
+	secret          []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+type accessClaims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func (a *authUsecase) Login(username, password string) (entity.AuthToken, error) {
+	user, err := a.userRepo.GetByUsername(strings.TrimSpace(username))
+	if apperror.Is(err, apperror.CodeNotFound) {
+		return entity.AuthToken{}, apperror.Unauthorized("invalid username or password")
+	}
+	if err != nil {
+		return entity.AuthToken{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return entity.AuthToken{}, apperror.Unauthorized("invalid username or password")
+	}
+	if !user.Aktif {
+		return entity.AuthToken{}, apperror.Unauthorized("user %s is not active", user.Username)
+	}
+
+	refresh, token, err := a.newRefreshToken()
+	if err != nil {
+		return entity.AuthToken{}, err
+	}
+	refresh.IDUser = user.IDUser
+	if err := a.userRepo.SaveRefreshToken(refresh); err != nil {
+		return entity.AuthToken{}, err
+	}
+
+	return a.issue(user, token, refresh.ExpiresAt)
+}
+
+func (a *authUsecase) Refresh(refreshToken string) (entity.AuthToken, error) {
+	if refreshToken == "" {
+		return entity.AuthToken{}, apperror.Invalid("refresh_token", "refresh_token cannot be empty")
+	}
+
+	next, token, err := a.newRefreshToken()
+	if err != nil {
+		return entity.AuthToken{}, err
+	}
+	user, err := a.userRepo.RotateRefreshToken(hashToken(refreshToken), next)
+	if err != nil {
+		return entity.AuthToken{}, err
+	}
+
+	return a.issue(user, token, next.ExpiresAt)
+}
+
+func (a *authUsecase) Logout(refreshToken string) error {
+	if refreshToken == "" {
+		return apperror.Invalid("refresh_token", "refresh_token cannot be empty")
+	}
+	return a.userRepo.RevokeRefreshToken(hashToken(refreshToken))
+}
+
+func (a *authUsecase) Authenticate(accessToken string) (entity.User, error) {
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return entity.User{}, apperror.Unauthorized("access token expired")
+		}
+		return entity.User{}, apperror.Unauthorized("invalid access token")
+	}
+
+	return entity.User{
+		IDUser:   claims.Subject,
+		Username: claims.Username,
+		Role:     claims.Role,
+		Aktif:    true,
+	}, nil
+}
+
+func (a *authUsecase) issue(user entity.User, refreshToken string, refreshExpiresAt time.Time) (entity.AuthToken, error) {
+	now := time.Now()
+	expiresAt := now.Add(a.accessTokenTTL)
+	claims := accessClaims{
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.IDUser,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return entity.AuthToken{}, err
+	}
+
+	return entity.AuthToken{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             user,
+	}, nil
+}
+
+// newRefreshToken returns a random refresh token and what is stored of it.
+func (a *authUsecase) newRefreshToken() (entity.RefreshToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return entity.RefreshToken{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return entity.RefreshToken{
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(a.refreshTokenTTL),
+	}, token, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewAuthUsecase(userRepo repository.UserRepository, secret string, accessTokenTTL, refreshTokenTTL time.Duration) AuthUsecase {
+	return &authUsecase{
+		userRepo:        userRepo,
+		secret:          []byte(secret),
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
@@ -4,56 +4,121 @@ import (
 	"fmt"
 	"roxy/entity"
 	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/barcode"
+	"slices"
 	"strings"
 )
 
 type MstBarangUseCase interface {
-	Create(barang entity.Barang) (entity.Barang, error)
-	List() ([]entity.Barang, error)
+	Create(actor entity.User, barang entity.Barang) (entity.Barang, error)
+	List(filter entity.BarangFilter) ([]entity.Barang, int, error)
 	GetByID(id string) (entity.Barang, error)
 	GetByName(name string) (entity.Barang, error)
-	Update(barang entity.Barang) (entity.Barang, error)
-	Delete(id string) error
+	GetBySku(sku string) (entity.Barang, error)
+	GetByBarcode(code string) (entity.Barang, error)
+	ListLowStock() ([]entity.Barang, error)
+	Search(keyword string, limit int) ([]entity.BarangSearchResult, error)
+	Update(actor entity.User, update entity.BarangUpdate) (entity.Barang, error)
+	// Delete removes a barang that was never stocked, ordered or sold, one
+	// with history is refused with a conflict.
+	Delete(actor entity.User, id string) error
 }
 
 type mstBarangUseCase struct {
 	barangRepository repository.MstBarangRepository
+	defaultMetodeHpp string
 }
 
-func (b *mstBarangUseCase) Create(barang entity.Barang) (entity.Barang, error) {
+func (b *mstBarangUseCase) Create(actor entity.User, barang entity.Barang) (entity.Barang, error) {
 	existBarang, _ := b.barangRepository.GetByName(barang.Nm_barang)
 	if strings.TrimSpace(barang.Nm_barang) == "" {
-		return entity.Barang{}, fmt.Errorf("name cannot be empty")
+		return entity.Barang{}, apperror.Invalid("nm_barang", "name cannot be empty")
 	}
 	if existBarang.Nm_barang == barang.Nm_barang {
-		return entity.Barang{}, fmt.Errorf("name already exist")
+		return entity.Barang{}, apperror.Conflict("name already exist")
 	}
 
-	return b.barangRepository.Create(barang)
+	if barang.MetodeHpp == "" {
+		barang.MetodeHpp = b.defaultMetodeHpp
+	}
+	if err := validateBarang(barang); err != nil {
+		return entity.Barang{}, err
+	}
+	if err := b.validateCodes(&barang, ""); err != nil {
+		return entity.Barang{}, err
+	}
+
+	return b.barangRepository.Create(actor, barang)
 }
 
-func (b *mstBarangUseCase) List() ([]entity.Barang, error) {
-	return b.barangRepository.List()
+// List sorts by id_barang unless told otherwise.
+func (b *mstBarangUseCase) List(filter entity.BarangFilter) ([]entity.Barang, int, error) {
+	if err := normalizePage(&filter.Page); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = "id_barang"
+	}
+	if !slices.Contains(entity.BarangSortColumns, filter.SortBy) {
+		return nil, 0, apperror.Invalid("sort", "sort must be one of %s", strings.Join(entity.BarangSortColumns, ", "))
+	}
+
+	if filter.MinHarga != nil && filter.MaxHarga != nil && *filter.MaxHarga < *filter.MinHarga {
+		return nil, 0, apperror.Invalid("max_harga", "max_harga cannot be less than min_harga")
+	}
+	if filter.MinQty != nil && filter.MaxQty != nil && *filter.MaxQty < *filter.MinQty {
+		return nil, 0, apperror.Invalid("max_qty", "max_qty cannot be less than min_qty")
+	}
+
+	return b.barangRepository.List(filter)
 }
 
 func (b *mstBarangUseCase) GetByID(id string) (entity.Barang, error) {
 	return b.barangRepository.GetByID(id)
 }
 
+// Search returns the best 10 matches unless told otherwise.
+func (b *mstBarangUseCase) Search(keyword string, limit int) ([]entity.BarangSearchResult, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, apperror.Invalid("q", "keyword cannot be empty")
+	}
+
+	if limit == 0 {
+		limit = 10
+	}
+	if limit < 0 || limit > entity.MaxPageLimit {
+		return nil, apperror.Invalid("limit", "limit must be between 1 and %d", entity.MaxPageLimit)
+	}
+
+	return b.barangRepository.Search(keyword, limit)
+}
+
+func (b *mstBarangUseCase) ListLowStock() ([]entity.Barang, error) {
+	return b.barangRepository.ListLowStock()
+}
+
 func (b *mstBarangUseCase) GetByName(name string) (entity.Barang, error) {
 	return b.barangRepository.GetByName(name)
 }
 
-func (b *mstBarangUseCase) Update(barang entity.Barang) (entity.Barang, error) {
+func (b *mstBarangUseCase) GetBySku(sku string) (entity.Barang, error) {
+	return b.barangRepository.GetBySku(strings.TrimSpace(sku))
+}
+
+func (b *mstBarangUseCase) Update(actor entity.User, update entity.BarangUpdate) (entity.Barang, error) {
+	barang := update.Barang
 	payload, err := b.barangRepository.GetByID(barang.Id_barang)
 	if err != nil {
-		return entity.Barang{}, fmt.Errorf("barang with ID %s not found", barang.Id_barang)
+		return entity.Barang{}, err
 	}
 
 	if strings.TrimSpace(barang.Nm_barang) != "" && barang.Nm_barang != payload.Nm_barang {
 		existBarang, _ := b.barangRepository.GetByName(barang.Nm_barang)
 		if existBarang.Id_barang != "" && existBarang.Id_barang != barang.Id_barang {
-			return entity.Barang{}, fmt.Errorf("name %s already exists", barang.Nm_barang)
+			return entity.Barang{}, apperror.Conflict("name %s already exists", barang.Nm_barang)
 		}
 	}
 
@@ -67,29 +132,133 @@ func (b *mstBarangUseCase) Update(barang entity.Barang) (entity.Barang, error) {
 	if barang.Harga == 0 {
 		barang.Harga = payload.Harga
 	}
+	if strings.TrimSpace(barang.Kategori) == "" {
+		barang.Kategori = payload.Kategori
+	}
+	if barang.MetodeHpp == "" {
+		barang.MetodeHpp = payload.MetodeHpp
+	}
+	barang.AllowNegativeStock = payload.AllowNegativeStock
+	if update.AllowNegativeStock != nil {
+		barang.AllowNegativeStock = *update.AllowNegativeStock
+	}
+	// 0 turns reorder tracking off, so only a missing field keeps it
+	barang.ReorderPoint = payload.ReorderPoint
+	if update.ReorderPoint != nil {
+		barang.ReorderPoint = *update.ReorderPoint
+	}
+	barang.ReorderQty = payload.ReorderQty
+	if update.ReorderQty != nil {
+		barang.ReorderQty = *update.ReorderQty
+	}
+	barang.LeadTimeHari = payload.LeadTimeHari
+	if update.LeadTimeHari != nil {
+		barang.LeadTimeHari = *update.LeadTimeHari
+	}
+	barang.SafetyStock = payload.SafetyStock
+	if update.SafetyStock != nil {
+		barang.SafetyStock = *update.SafetyStock
+	}
+	if err := validateBarang(barang); err != nil {
+		return entity.Barang{}, err
+	}
+	if strings.TrimSpace(barang.Sku) == "" {
+		barang.Sku = payload.Sku
+	}
+	if err := b.validateCodes(&barang, barang.Id_barang); err != nil {
+		return entity.Barang{}, err
+	}
 
-	updatedBarang, err := b.barangRepository.Update(barang)
+	updatedBarang, err := b.barangRepository.Update(actor, barang)
 	if err != nil {
-		return entity.Barang{}, fmt.Errorf("failed to update barang: %v", err)
+		return entity.Barang{}, fmt.Errorf("failed to update barang: %w", err)
 	}
 
 	return updatedBarang, nil
 }
 
-func (b *mstBarangUseCase) Delete(id string) error {
+func (b *mstBarangUseCase) Delete(actor entity.User, id string) error {
 	_, err := b.barangRepository.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("barang with ID %s not found", id)
+		return err
 	}
 
-	err = b.barangRepository.Delete(id)
+	err = b.barangRepository.Delete(actor, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete barang: %v", err)
+		return fmt.Errorf("failed to delete barang: %w", err)
 	}
 
 	return nil
 }
 
-func NewBarangUseCase(barangRepository repository.MstBarangRepository) MstBarangUseCase {
-	return &mstBarangUseCase{barangRepository: barangRepository}
+func (b *mstBarangUseCase) GetByBarcode(code string) (entity.Barang, error) {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return entity.Barang{}, err
+	}
+	return b.barangRepository.GetByBarcode(code)
+}
+
+// validateCodes normalizes the sku and barcodes of the barang and checks that
+// none of them belong to another barang than idBarang.
+func (b *mstBarangUseCase) validateCodes(barang *entity.Barang, idBarang string) error {
+	barang.Sku = strings.TrimSpace(barang.Sku)
+	if len(barang.Sku) > 50 {
+		return apperror.Invalid("sku", "sku cannot be longer than 50 characters")
+	}
+	for _, c := range barang.Sku {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return apperror.Invalid("sku", "sku may only contain letters, digits, '-', '_' and '.'")
+		}
+	}
+	if barang.Sku != "" {
+		existBarang, _ := b.barangRepository.GetBySku(barang.Sku)
+		if existBarang.Id_barang != "" && existBarang.Id_barang != idBarang {
+			return apperror.Conflict("sku %s already exist", barang.Sku)
+		}
+	}
+
+	for i, code := range barang.Barcodes {
+		code, err := barcode.Normalize(code)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(barang.Barcodes[:i], code) {
+			return apperror.Invalid("barcodes", "barcode %s is listed twice", code)
+		}
+		existBarang, _ := b.barangRepository.GetByBarcode(code)
+		if existBarang.Id_barang != "" && existBarang.Id_barang != idBarang {
+			return apperror.Conflict("barcode %s already exist on barang %s", code, existBarang.Id_barang)
+		}
+		barang.Barcodes[i] = code
+	}
+	return nil
+}
+
+// validateBarang reports every field of the barang that is out of range.
+func validateBarang(barang entity.Barang) error {
+	var fields apperror.FieldErrors
+	if barang.MetodeHpp != entity.CostingAverage && barang.MetodeHpp != entity.CostingFIFO {
+		fields.Add("metode_hpp", "metode_hpp must be %s or %s", entity.CostingAverage, entity.CostingFIFO)
+	}
+	if barang.HargaPokok < 0 {
+		fields.Add("harga_pokok", "harga_pokok cannot be negative")
+	}
+	if barang.ReorderPoint < 0 {
+		fields.Add("reorder_point", "reorder_point cannot be negative")
+	}
+	if barang.ReorderQty < 0 {
+		fields.Add("reorder_qty", "reorder_qty cannot be negative")
+	}
+	if barang.LeadTimeHari < 0 {
+		fields.Add("lead_time_hari", "lead_time_hari cannot be negative")
+	}
+	if barang.SafetyStock < 0 {
+		fields.Add("safety_stock", "safety_stock cannot be negative")
+	}
+	return fields.Err()
+}
+
+func NewBarangUseCase(barangRepository repository.MstBarangRepository, defaultMetodeHpp string) MstBarangUseCase {
+	return &mstBarangUseCase{barangRepository: barangRepository, defaultMetodeHpp: defaultMetodeHpp}
 }
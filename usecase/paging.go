@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/shared/apperror"
+)
+
+// normalizePage fills in the first page and the default limit, and rejects
+// limits above entity.MaxPageLimit.
+func normalizePage(page *entity.Page) error {
+	if page.Page == 0 {
+		page.Page = 1
+	}
+	if page.Limit == 0 {
+		page.Limit = entity.DefaultPageLimit
+	}
+	if page.Page < 0 || page.Limit < 0 {
+		return apperror.Validation("page and limit cannot be negative")
+	}
+	if page.Limit > entity.MaxPageLimit {
+		return apperror.Invalid("limit", "limit cannot be more than %d", entity.MaxPageLimit)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+)
+
+type PaymentUsecase interface {
+	AddPayments(actor entity.User, idTrans string, payments []entity.Payment) (entity.TransaksiHeader, []entity.Payment, error)
+	ListByTransaksi(idTrans string) ([]entity.Payment, error)
+}
+
+type paymentUsecase struct {
+	paymentRepo   repository.PaymentRepository
+	transaksiRepo repository.TransaksiRepository
+}
+
+func (p *paymentUsecase) AddPayments(actor entity.User, idTrans string, payments []entity.Payment) (entity.TransaksiHeader, []entity.Payment, error) {
+	if len(payments) == 0 {
+		return entity.TransaksiHeader{}, payments, apperror.Invalid("payments", "pembayaran tidak boleh kosong")
+	}
+	if err := validatePayments(payments); err != nil {
+		return entity.TransaksiHeader{}, payments, err
+	}
+
+	return p.paymentRepo.AddPayments(actor, idTrans, payments)
+}
+
+func (p *paymentUsecase) ListByTransaksi(idTrans string) ([]entity.Payment, error) {
+	if _, _, err := p.transaksiRepo.GetTransaksiByID(idTrans); err != nil {
+		return nil, err
+	}
+
+	return p.paymentRepo.ListByTransaksi(idTrans)
+}
+
+func validatePayments(payments []entity.Payment) error {
+	for _, payment := range payments {
+		switch payment.Metode {
+		case entity.PaymentCash, entity.PaymentDebitCard, entity.PaymentQRIS, entity.PaymentEWallet, entity.PaymentStoreCredit:
+		default:
+			return apperror.Invalid("payments.metode", "metode pembayaran %q tidak dikenal", payment.Metode)
+		}
+		if payment.Jumlah <= 0 {
+			return apperror.Invalid("payments.jumlah", "jumlah pembayaran harus lebih dari 0")
+		}
+	}
+	return nil
+}
+
+func NewPaymentUsecase(paymentRepo repository.PaymentRepository, transaksiRepo repository.TransaksiRepository) PaymentUsecase {
+	return &paymentUsecase{paymentRepo: paymentRepo, transaksiRepo: transaksiRepo}
+}
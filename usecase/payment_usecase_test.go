@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"testing"
+)
+
+// fakePaymentRepo records whether payments reached the database.
+type fakePaymentRepo struct {
+	repository.PaymentRepository
+	added bool
+}
+
+func (f *fakePaymentRepo) AddPayments(actor entity.User, idTrans string, payments []entity.Payment) (entity.TransaksiHeader, []entity.Payment, error) {
+	f.added = true
+	return entity.TransaksiHeader{IDTrans: idTrans}, payments, nil
+}
+
+func TestValidatePayments(t *testing.T) {
+	tests := []struct {
+		name     string
+		payments []entity.Payment
+		valid    bool
+	}{
+		{"cash", []entity.Payment{{Metode: entity.PaymentCash, Jumlah: money.New(50000)}}, true},
+		{"every metode", []entity.Payment{
+			{Metode: entity.PaymentCash, Jumlah: 1},
+			{Metode: entity.PaymentDebitCard, Jumlah: 1},
+			{Metode: entity.PaymentQRIS, Jumlah: 1},
+			{Metode: entity.PaymentEWallet, Jumlah: 1},
+			{Metode: entity.PaymentStoreCredit, Jumlah: 1},
+		}, true},
+		{"none", nil, true},
+		{"unknown metode", []entity.Payment{{Metode: "cek", Jumlah: money.New(50000)}}, false},
+		{"no metode", []entity.Payment{{Jumlah: money.New(50000)}}, false},
+		{"zero jumlah", []entity.Payment{{Metode: entity.PaymentCash}}, false},
+		{"negative jumlah", []entity.Payment{{Metode: entity.PaymentCash, Jumlah: money.New(10000)}, {Metode: entity.PaymentQRIS, Jumlah: -1}}, false},
+	}
+	for _, tt := range tests {
+		err := validatePayments(tt.payments)
+		if tt.valid && err != nil {
+			t.Errorf("%s: validatePayments returned error %v", tt.name, err)
+		}
+		if !tt.valid && !apperror.Is(err, apperror.CodeValidation) {
+			t.Errorf("%s: validatePayments error = %v, want a validation error", tt.name, err)
+		}
+	}
+}
+
+func TestAddPaymentsInvalid(t *testing.T) {
+	for _, payments := range [][]entity.Payment{nil, {{Metode: entity.PaymentCash}}} {
+		paymentRepo := &fakePaymentRepo{}
+		_, _, err := NewPaymentUsecase(paymentRepo, nil).AddPayments(entity.User{}, "TR-1", payments)
+		if !apperror.Is(err, apperror.CodeValidation) {
+			t.Errorf("AddPayments(%v) error = %v, want a validation error", payments, err)
+		}
+		if paymentRepo.added {
+			t.Errorf("AddPayments(%v) reached the repository", payments)
+		}
+	}
+}
+
+func TestCreateTransaksiPaymentsSettle(t *testing.T) {
+	barang := map[string]entity.Barang{"BR-1": {Id_barang: "BR-1", Harga: money.New(10000)}}
+	// 3 x 10000 with 11% pajak is 33300
+	details := []entity.TransaksiDetail{{IDBarang: "BR-1", Qty: 3}}
+
+	tests := []struct {
+		name     string
+		payments []entity.Payment
+		valid    bool
+	}{
+		{"no payments, paid later", nil, true},
+		{"exact", []entity.Payment{{Metode: entity.PaymentCash, Jumlah: money.New(33300)}}, true},
+		{"cash over the total", []entity.Payment{{Metode: entity.PaymentCash, Jumlah: money.New(50000)}}, true},
+		{"split", []entity.Payment{{Metode: entity.PaymentQRIS, Jumlah: money.New(20000)}, {Metode: entity.PaymentCash, Jumlah: money.New(13300)}}, true},
+		{"short", []entity.Payment{{Metode: entity.PaymentCash, Jumlah: money.New(33299)}}, false},
+		{"invalid metode", []entity.Payment{{Metode: "cek", Jumlah: money.New(33300)}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaksiRepo := &fakeTransaksiRepo{}
+			uc := NewTransaksiUsecase(transaksiRepo, &fakeBarangRepo{barang: barang}, 11)
+
+			_, err := uc.CreateTransaksiWithDetail(entity.User{Role: entity.RoleKasir}, entity.TransaksiHeader{}, append([]entity.TransaksiDetail(nil), details...), tt.payments)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("CreateTransaksiWithDetail returned error %v", err)
+				}
+				if transaksiRepo.header.Total != money.New(33300) {
+					t.Errorf("total = %s, want 33300.00", transaksiRepo.header.Total)
+				}
+				return
+			}
+			if !apperror.Is(err, apperror.CodeValidation) {
+				t.Errorf("error = %v, want a validation error", err)
+			}
+			if transaksiRepo.created {
+				t.Error("the transaksi was created")
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"time"
+)
+
+type PenerimaanUsecase interface {
+	CreatePenerimaan(header entity.PenerimaanHeader, details []entity.PenerimaanDetail) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error)
+	ListPenerimaan(filter entity.PenerimaanFilter) ([]entity.PenerimaanHeader, error)
+	GetPenerimaanByID(idPenerimaan string) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error)
+}
+
+type penerimaanUsecase struct {
+	penerimaanRepo repository.PenerimaanRepository
+	supplierRepo   repository.SupplierRepository
+	barangRepo     repository.MstBarangRepository
+	poRepo         repository.PurchaseOrderRepository
+	poTolerance    int
+}
+
+func (p *penerimaanUsecase) CreatePenerimaan(header entity.PenerimaanHeader, details []entity.PenerimaanDetail) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error) {
+	if len(details) == 0 {
+		return header, details, apperror.Invalid("detail", "penerimaan detail tidak boleh kosong")
+	}
+
+	if header.IDPO != "" {
+		if err := p.matchPurchaseOrder(&header, details); err != nil {
+			return header, details, err
+		}
+	} else {
+		for _, detail := range details {
+			if detail.IDPODetail != "" {
+				return header, details, apperror.Invalid("header.id_po", "id_po harus diisi untuk penerimaan atas purchase order")
+			}
+		}
+	}
+
+	if _, err := p.supplierRepo.GetByID(header.IDSupplier); err != nil {
+		return header, details, err
+	}
+
+	if header.TglPenerimaan.IsZero() {
+		header.TglPenerimaan = time.Now()
+	}
+	header.TglPenerimaan = header.TglPenerimaan.UTC()
+
+	var total money.Money
+	for i := range details {
+		if details[i].Qty <= 0 {
+			return header, details, apperror.Invalid("detail.qty", "qty harus lebih dari 0")
+		}
+		if details[i].HargaBeli < 0 {
+			return header, details, apperror.Invalid("detail.harga_beli", "harga beli tidak boleh minus")
+		}
+
+		if _, err := p.barangRepo.GetByID(details[i].IDBarang); err != nil {
+			return header, details, err
+		}
+
+		details[i].Subtotal = details[i].HargaBeli.Mul(details[i].Qty)
+		total += details[i].Subtotal
+	}
+
+	header.Total = total
+
+	return p.penerimaanRepo.CreatePenerimaan(header, details, p.poTolerance)
+}
+
+// matchPurchaseOrder fills supplier, barang and harga beli of a receipt from
+// the purchase order it is booked against. The outstanding qty is checked by
+// the repository under lock.
+func (p *penerimaanUsecase) matchPurchaseOrder(header *entity.PenerimaanHeader, details []entity.PenerimaanDetail) error {
+	po, poDetails, err := p.poRepo.GetPurchaseOrderByID(header.IDPO)
+	if err != nil {
+		return err
+	}
+
+	if header.IDSupplier == "" {
+		header.IDSupplier = po.IDSupplier
+	}
+	if header.IDSupplier != po.IDSupplier {
+		return apperror.Validation("purchase order %s bukan milik supplier %s", po.IDPO, header.IDSupplier)
+	}
+
+	lines := make(map[string]entity.PurchaseOrderDetail, len(poDetails))
+	for _, line := range poDetails {
+		lines[line.IDPODetail] = line
+	}
+
+	for i := range details {
+		line, ok := lines[details[i].IDPODetail]
+		if !ok {
+			return apperror.Validation("detail %s bukan bagian dari purchase order %s", details[i].IDPODetail, po.IDPO)
+		}
+		if details[i].IDBarang != "" && details[i].IDBarang != line.IDBarang {
+			return apperror.Validation("barang %s tidak sesuai dengan detail %s", details[i].IDBarang, line.IDPODetail)
+		}
+		details[i].IDBarang = line.IDBarang
+		if details[i].HargaBeli == 0 {
+			details[i].HargaBeli = line.HargaBeli
+		}
+	}
+
+	return nil
+}
+
+func (p *penerimaanUsecase) ListPenerimaan(filter entity.PenerimaanFilter) ([]entity.PenerimaanHeader, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, apperror.Invalid("to", "tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+
+	return p.penerimaanRepo.ListPenerimaan(filter)
+}
+
+func (p *penerimaanUsecase) GetPenerimaanByID(idPenerimaan string) (entity.PenerimaanHeader, []entity.PenerimaanDetail, error) {
+	return p.penerimaanRepo.GetPenerimaanByID(idPenerimaan)
+}
+
+func NewPenerimaanUsecase(penerimaanRepo repository.PenerimaanRepository, supplierRepo repository.SupplierRepository, barangRepo repository.MstBarangRepository, poRepo repository.PurchaseOrderRepository, poTolerance int) PenerimaanUsecase {
+	return &penerimaanUsecase{
+		penerimaanRepo: penerimaanRepo,
+		supplierRepo:   supplierRepo,
+		barangRepo:     barangRepo,
+		poRepo:         poRepo,
+		poTolerance:    poTolerance,
+	}
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"time"
+)
+
+type PurchaseOrderUsecase interface {
+	CreatePurchaseOrder(header entity.PurchaseOrderHeader, details []entity.PurchaseOrderDetail) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error)
+	ListPurchaseOrder(filter entity.PurchaseOrderFilter) ([]entity.PurchaseOrderHeader, error)
+	GetPurchaseOrderByID(idPO string) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error)
+	SendPurchaseOrder(idPO string) (entity.PurchaseOrderHeader, error)
+	CancelPurchaseOrder(idPO string) (entity.PurchaseOrderHeader, error)
+}
+
+type purchaseOrderUsecase struct {
+	poRepo       repository.PurchaseOrderRepository
+	supplierRepo repository.SupplierRepository
+	barangRepo   repository.MstBarangRepository
+}
+
+func (p *purchaseOrderUsecase) CreatePurchaseOrder(header entity.PurchaseOrderHeader, details []entity.PurchaseOrderDetail) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error) {
+	if len(details) == 0 {
+		return header, details, apperror.Invalid("detail", "purchase order detail tidak boleh kosong")
+	}
+
+	if _, err := p.supplierRepo.GetByID(header.IDSupplier); err != nil {
+		return header, details, err
+	}
+
+	if header.TglPO.IsZero() {
+		header.TglPO = time.Now()
+	}
+	header.TglPO = header.TglPO.UTC()
+	header.Status = entity.PODraft
+
+	var total money.Money
+	for i := range details {
+		if details[i].Qty <= 0 {
+			return header, details, apperror.Invalid("detail.qty", "qty harus lebih dari 0")
+		}
+		if details[i].HargaBeli < 0 {
+			return header, details, apperror.Invalid("detail.harga_beli", "harga beli tidak boleh minus")
+		}
+
+		if _, err := p.barangRepo.GetByID(details[i].IDBarang); err != nil {
+			return header, details, err
+		}
+
+		details[i].QtyDiterima = 0
+		details[i].Subtotal = details[i].HargaBeli.Mul(details[i].Qty)
+		total += details[i].Subtotal
+	}
+
+	header.Total = total
+
+	return p.poRepo.CreatePurchaseOrder(header, details)
+}
+
+func (p *purchaseOrderUsecase) ListPurchaseOrder(filter entity.PurchaseOrderFilter) ([]entity.PurchaseOrderHeader, error) {
+	return p.poRepo.ListPurchaseOrder(filter)
+}
+
+func (p *purchaseOrderUsecase) GetPurchaseOrderByID(idPO string) (entity.PurchaseOrderHeader, []entity.PurchaseOrderDetail, error) {
+	return p.poRepo.GetPurchaseOrderByID(idPO)
+}
+
+func (p *purchaseOrderUsecase) SendPurchaseOrder(idPO string) (entity.PurchaseOrderHeader, error) {
+	return p.poRepo.UpdateStatus(idPO, entity.POSent, []string{entity.PODraft})
+}
+
+// CancelPurchaseOrder drops whatever is still outstanding, goods that were
+// already received stay in stock.
+func (p *purchaseOrderUsecase) CancelPurchaseOrder(idPO string) (entity.PurchaseOrderHeader, error) {
+	return p.poRepo.UpdateStatus(idPO, entity.POCancelled, []string{entity.PODraft, entity.POSent, entity.POPartiallyReceived})
+}
+
+func NewPurchaseOrderUsecase(poRepo repository.PurchaseOrderRepository, supplierRepo repository.SupplierRepository, barangRepo repository.MstBarangRepository) PurchaseOrderUsecase {
+	return &purchaseOrderUsecase{
+		poRepo:       poRepo,
+		supplierRepo: supplierRepo,
+		barangRepo:   barangRepo,
+	}
+}
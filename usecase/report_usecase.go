@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"math"
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"slices"
+	"strings"
+	"time"
+)
+
+type ReportUsecase interface {
+	InventoryValuation(asOf time.Time) (entity.InventoryValuation, error)
+	GrossProfit(filter entity.GrossProfitFilter) ([]entity.GrossProfitRow, error)
+	SalesSummary(filter entity.SalesSummaryFilter) ([]entity.SalesSummaryRow, error)
+	TopItems(filter entity.TopItemFilter) ([]entity.TopItemRow, error)
+	SlowMovers(days int) ([]entity.SlowMoverRow, error)
+	Replenishment(historyDays int, coverageDays int) ([]entity.ReplenishmentRow, error)
+}
+
+type reportUsecase struct {
+	reportRepo repository.ReportRepository
+}
+
+// InventoryValuation values the stock as of asOf, or as of now when asOf is
+// zero.
+func (r *reportUsecase) InventoryValuation(asOf time.Time) (entity.InventoryValuation, error) {
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+
+	return r.reportRepo.InventoryValuation(asOf)
+}
+
+// GrossProfit groups by item, sorts by grup and takes days in UTC unless told
+// otherwise.
+func (r *reportUsecase) GrossProfit(filter entity.GrossProfitFilter) ([]entity.GrossProfitRow, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, apperror.Invalid("to", "tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+
+	if filter.GroupBy == "" {
+		filter.GroupBy = entity.ReportByItem
+	}
+	groups := []string{entity.ReportByItem, entity.ReportByKategori, entity.ReportByDay, entity.ReportByWeek, entity.ReportByMonth}
+	if !slices.Contains(groups, filter.GroupBy) {
+		return nil, apperror.Invalid("group_by", "group_by must be one of %s", strings.Join(groups, ", "))
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = "grup"
+	}
+	if !slices.Contains(entity.GrossProfitColumns, filter.SortBy) {
+		return nil, apperror.Invalid("sort", "sort must be one of %s", strings.Join(entity.GrossProfitColumns, ", "))
+	}
+
+	if filter.Timezone == "" {
+		filter.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(filter.Timezone); err != nil {
+		return nil, apperror.Invalid("tz", "unknown timezone %s", filter.Timezone)
+	}
+
+	return r.reportRepo.GrossProfit(filter)
+}
+
+// SalesSummary buckets by day in UTC unless told otherwise.
+func (r *reportUsecase) SalesSummary(filter entity.SalesSummaryFilter) ([]entity.SalesSummaryRow, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, apperror.Invalid("to", "tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+
+	if filter.Interval == "" {
+		filter.Interval = entity.IntervalDay
+	}
+	intervals := []string{entity.IntervalDay, entity.IntervalWeek, entity.IntervalMonth}
+	if !slices.Contains(intervals, filter.Interval) {
+		return nil, apperror.Invalid("interval", "interval must be one of %s", strings.Join(intervals, ", "))
+	}
+
+	if filter.Timezone == "" {
+		filter.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(filter.Timezone); err != nil {
+		return nil, apperror.Invalid("tz", "unknown timezone %s", filter.Timezone)
+	}
+
+	return r.reportRepo.SalesSummary(filter)
+}
+
+// TopItems ranks by qty over the last 30 days unless told otherwise.
+func (r *reportUsecase) TopItems(filter entity.TopItemFilter) ([]entity.TopItemRow, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, apperror.Invalid("to", "tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+	if filter.From.IsZero() && filter.To.IsZero() {
+		filter.From = time.Now().UTC().AddDate(0, 0, -30)
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = "qty"
+	}
+	if filter.SortBy != "qty" && filter.SortBy != "pendapatan" {
+		return nil, apperror.Invalid("sort", "sort must be qty or pendapatan")
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+	if filter.Limit < 0 {
+		return nil, apperror.Invalid("limit", "limit tidak boleh minus")
+	}
+
+	return r.reportRepo.TopItems(filter)
+}
+
+// SlowMovers lists barang in stock that did not sell in the last days days.
+func (r *reportUsecase) SlowMovers(days int) ([]entity.SlowMoverRow, error) {
+	if days <= 0 {
+		return nil, apperror.Invalid("days", "days harus lebih dari 0")
+	}
+
+	return r.reportRepo.SlowMovers(time.Now().AddDate(0, 0, -days))
+}
+
+// Replenishment proposes reorders from the average daily demand over the
+// last historyDays. A barang is proposed when its stock plus what is on order,
+// minus the demand over its lead time and the coverage window, would fall
+// below its safety stock. The proposed qty brings it back to the safety stock
+// at the end of that horizon, rounded up to whole multiples of reorder_qty.
+func (r *reportUsecase) Replenishment(historyDays int, coverageDays int) ([]entity.ReplenishmentRow, error) {
+	if historyDays <= 0 {
+		return nil, apperror.Invalid("history_days", "history_days harus lebih dari 0")
+	}
+	if coverageDays < 0 {
+		return nil, apperror.Invalid("coverage_days", "coverage_days tidak boleh minus")
+	}
+
+	inputs, err := r.reportRepo.ReplenishmentInputs(time.Now().UTC().AddDate(0, 0, -historyDays))
+	if err != nil {
+		return nil, err
+	}
+
+	var proposal []entity.ReplenishmentRow
+	for _, input := range inputs {
+		demand := float64(max(input.Terjual, 0)) / float64(historyDays)
+		horizon := float64(input.LeadTimeHari + coverageDays)
+
+		available := float64(input.Qty + input.DalamPesanan)
+		needed := demand*horizon + float64(input.SafetyStock)
+		if available >= needed {
+			continue
+		}
+
+		orderQty := int(math.Ceil(needed - available))
+		if input.ReorderQty > 0 {
+			orderQty = (orderQty + input.ReorderQty - 1) / input.ReorderQty * input.ReorderQty
+		}
+
+		row := entity.ReplenishmentRow{
+			IDBarang:       input.IDBarang,
+			NmBarang:       input.NmBarang,
+			Qty:            input.Qty,
+			DalamPesanan:   input.DalamPesanan,
+			RataRataHarian: math.Round(demand*100) / 100,
+			LeadTimeHari:   input.LeadTimeHari,
+			SafetyStock:    input.SafetyStock,
+			SaranPesan:     orderQty,
+			EstimasiBiaya:  input.HargaPokok.Mul(orderQty),
+		}
+		if demand > 0 {
+			days := math.Round(max(float64(input.Qty-input.SafetyStock), 0)/demand*10) / 10
+			row.HariSampaiHabis = &days
+		}
+		proposal = append(proposal, row)
+	}
+
+	return proposal, nil
+}
+
+func NewReportUsecase(reportRepo repository.ReportRepository) ReportUsecase {
+	return &reportUsecase{reportRepo: reportRepo}
+}
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"testing"
+	"time"
+)
+
+// fakeReportRepo serves fixed replenishment inputs and records the gross
+// profit filter it is sent.
+type fakeReportRepo struct {
+	repository.ReportRepository
+	inputs []entity.ReplenishmentInput
+	filter *entity.GrossProfitFilter
+}
+
+func (f *fakeReportRepo) GrossProfit(filter entity.GrossProfitFilter) ([]entity.GrossProfitRow, error) {
+	f.filter = &filter
+	return nil, nil
+}
+
+func (f *fakeReportRepo) ReplenishmentInputs(since time.Time) ([]entity.ReplenishmentInput, error) {
+	return f.inputs, nil
+}
+
+func TestReplenishment(t *testing.T) {
+	days := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name        string
+		input       entity.ReplenishmentInput
+		coverage    int
+		proposed    bool
+		saranPesan  int
+		rataRata    float64
+		sampaiHabis *float64
+	}{
+		{
+			name:     "enough stock",
+			input:    entity.ReplenishmentInput{Qty: 100, Terjual: 30, LeadTimeHari: 7, SafetyStock: 5},
+			coverage: 7,
+		},
+		{
+			// 2 a day over 5 + 7 days plus 3 safety is 27, 10 in stock
+			name:        "below what the horizon needs",
+			input:       entity.ReplenishmentInput{Qty: 10, Terjual: 60, LeadTimeHari: 5, SafetyStock: 3},
+			coverage:    7,
+			proposed:    true,
+			saranPesan:  17,
+			rataRata:    2,
+			sampaiHabis: days(3.5),
+		},
+		{
+			name:        "rounded up to reorder_qty",
+			input:       entity.ReplenishmentInput{Qty: 10, Terjual: 60, LeadTimeHari: 5, SafetyStock: 3, ReorderQty: 12},
+			coverage:    7,
+			proposed:    true,
+			saranPesan:  24,
+			rataRata:    2,
+			sampaiHabis: days(3.5),
+		},
+		{
+			name:        "reorder_qty already met",
+			input:       entity.ReplenishmentInput{Qty: 10, Terjual: 60, LeadTimeHari: 5, SafetyStock: 3, ReorderQty: 17},
+			coverage:    7,
+			proposed:    true,
+			saranPesan:  17,
+			rataRata:    2,
+			sampaiHabis: days(3.5),
+		},
+		{
+			name:     "covered by what is on order",
+			input:    entity.ReplenishmentInput{Qty: 10, DalamPesanan: 17, Terjual: 60, LeadTimeHari: 5, SafetyStock: 3},
+			coverage: 7,
+		},
+		{
+			name:        "partly covered by what is on order",
+			input:       entity.ReplenishmentInput{Qty: 10, DalamPesanan: 7, Terjual: 60, LeadTimeHari: 5, SafetyStock: 3},
+			coverage:    7,
+			proposed:    true,
+			saranPesan:  10,
+			rataRata:    2,
+			sampaiHabis: days(3.5),
+		},
+		{
+			// 1.5 a day over 2 + 7 days is 13.5
+			name:        "fractional demand rounds the order up",
+			input:       entity.ReplenishmentInput{Qty: 10, Terjual: 45, LeadTimeHari: 2},
+			coverage:    7,
+			proposed:    true,
+			saranPesan:  4,
+			rataRata:    1.5,
+			sampaiHabis: days(6.7),
+		},
+		{
+			name:       "no sales but below safety stock",
+			input:      entity.ReplenishmentInput{Qty: 2, SafetyStock: 5},
+			coverage:   7,
+			proposed:   true,
+			saranPesan: 3,
+		},
+		{
+			name:     "no sales at safety stock",
+			input:    entity.ReplenishmentInput{Qty: 5, SafetyStock: 5},
+			coverage: 7,
+		},
+		{
+			name:     "more returned than sold counts as no demand",
+			input:    entity.ReplenishmentInput{Qty: 5, Terjual: -6, SafetyStock: 5},
+			coverage: 7,
+		},
+		{
+			name:       "negative stock is ordered back up",
+			input:      entity.ReplenishmentInput{Qty: -4},
+			proposed:   true,
+			saranPesan: 4,
+		},
+		{
+			name:        "no coverage window, only the lead time",
+			input:       entity.ReplenishmentInput{Qty: 10, Terjual: 60, LeadTimeHari: 6},
+			proposed:    true,
+			saranPesan:  2,
+			rataRata:    2,
+			sampaiHabis: days(5),
+		},
+		{
+			name:        "stock under safety runs out now",
+			input:       entity.ReplenishmentInput{Qty: 2, Terjual: 30, SafetyStock: 5},
+			coverage:    7,
+			proposed:    true,
+			saranPesan:  10,
+			rataRata:    1,
+			sampaiHabis: days(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			input.IDBarang = "BR-1"
+			input.HargaPokok = money.New(1500)
+			uc := NewReportUsecase(&fakeReportRepo{inputs: []entity.ReplenishmentInput{input}})
+
+			proposal, err := uc.Replenishment(30, tt.coverage)
+			if err != nil {
+				t.Fatalf("Replenishment returned error %v", err)
+			}
+			if !tt.proposed {
+				if len(proposal) != 0 {
+					t.Errorf("proposal = %+v, want none", proposal)
+				}
+				return
+			}
+			if len(proposal) != 1 {
+				t.Fatalf("proposal = %+v, want one row", proposal)
+			}
+
+			row := proposal[0]
+			if row.SaranPesan != tt.saranPesan {
+				t.Errorf("saran_pesan = %d, want %d", row.SaranPesan, tt.saranPesan)
+			}
+			if row.EstimasiBiaya != money.New(1500).Mul(tt.saranPesan) {
+				t.Errorf("estimasi_biaya = %s, want %d x 1500", row.EstimasiBiaya, tt.saranPesan)
+			}
+			if row.RataRataHarian != tt.rataRata {
+				t.Errorf("rata_rata_harian = %v, want %v", row.RataRataHarian, tt.rataRata)
+			}
+			switch {
+			case tt.sampaiHabis == nil && row.HariSampaiHabis != nil:
+				t.Errorf("hari_sampai_habis = %v, want none", *row.HariSampaiHabis)
+			case tt.sampaiHabis != nil && (row.HariSampaiHabis == nil || *row.HariSampaiHabis != *tt.sampaiHabis):
+				t.Errorf("hari_sampai_habis = %v, want %v", row.HariSampaiHabis, *tt.sampaiHabis)
+			}
+		})
+	}
+}
+
+func TestReplenishmentInvalid(t *testing.T) {
+	uc := NewReportUsecase(&fakeReportRepo{})
+	for _, tt := range []struct{ history, coverage int }{{0, 7}, {-30, 7}, {30, -1}} {
+		if _, err := uc.Replenishment(tt.history, tt.coverage); !apperror.Is(err, apperror.CodeValidation) {
+			t.Errorf("Replenishment(%d, %d) error = %v, want a validation error", tt.history, tt.coverage, err)
+		}
+	}
+}
+
+func TestGrossProfitTimezone(t *testing.T) {
+	tests := []struct {
+		tz    string
+		want  string
+		valid bool
+	}{
+		{"", "UTC", true},
+		{"UTC", "UTC", true},
+		{"Asia/Jakarta", "Asia/Jakarta", true},
+		{"Asia/Makassar", "Asia/Makassar", true},
+		{"Asia/Bandung", "", false},
+		{"+07:00", "", false},
+	}
+	for _, tt := range tests {
+		reportRepo := &fakeReportRepo{}
+		_, err := NewReportUsecase(reportRepo).GrossProfit(entity.GrossProfitFilter{GroupBy: entity.ReportByDay, Timezone: tt.tz})
+		if !tt.valid {
+			if !apperror.Is(err, apperror.CodeValidation) {
+				t.Errorf("GrossProfit with tz %q error = %v, want a validation error", tt.tz, err)
+			}
+			if reportRepo.filter != nil {
+				t.Errorf("GrossProfit with tz %q reached the repository", tt.tz)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("GrossProfit with tz %q returned error %v", tt.tz, err)
+			continue
+		}
+		if reportRepo.filter.Timezone != tt.want {
+			t.Errorf("GrossProfit with tz %q sent tz %q, want %q", tt.tz, reportRepo.filter.Timezone, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"strings"
+	"time"
+)
+
+type ReturUsecase interface {
+	CreateRetur(actor entity.User, idTrans string, header entity.ReturHeader, details []entity.ReturDetail) (entity.ReturHeader, []entity.ReturDetail, error)
+	ListReturByTransaksi(idTrans string) ([]entity.ReturHeader, error)
+	GetReturByID(idTrans string, idRetur string) (entity.ReturHeader, []entity.ReturDetail, error)
+}
+
+type returUsecase struct {
+	returRepo     repository.ReturRepository
+	transaksiRepo repository.TransaksiRepository
+}
+
+func (r *returUsecase) CreateRetur(actor entity.User, idTrans string, header entity.ReturHeader, details []entity.ReturDetail) (entity.ReturHeader, []entity.ReturDetail, error) {
+	if len(details) == 0 {
+		return header, details, apperror.Invalid("detail", "retur detail tidak boleh kosong")
+	}
+	if strings.TrimSpace(header.Alasan) == "" {
+		return header, details, apperror.Invalid("alasan", "alasan retur tidak boleh kosong")
+	}
+
+	for _, detail := range details {
+		if detail.IDTransDetail == "" {
+			return header, details, apperror.Invalid("detail.id_trans_detail", "id_trans_detail harus diisi")
+		}
+		if detail.Qty <= 0 {
+			return header, details, apperror.Invalid("detail.qty", "qty harus lebih dari 0")
+		}
+	}
+
+	header.IDTrans = idTrans
+	if header.TglRetur.IsZero() {
+		header.TglRetur = time.Now()
+	}
+	header.TglRetur = header.TglRetur.UTC()
+
+	return r.returRepo.CreateRetur(actor, header, details)
+}
+
+func (r *returUsecase) ListReturByTransaksi(idTrans string) ([]entity.ReturHeader, error) {
+	if _, _, err := r.transaksiRepo.GetTransaksiByID(idTrans); err != nil {
+		return nil, err
+	}
+
+	return r.returRepo.ListReturByTransaksi(idTrans)
+}
+
+func (r *returUsecase) GetReturByID(idTrans string, idRetur string) (entity.ReturHeader, []entity.ReturDetail, error) {
+	header, details, err := r.returRepo.GetReturByID(idRetur)
+	if err != nil {
+		return header, details, err
+	}
+
+	if header.IDTrans != idTrans {
+		return entity.ReturHeader{}, nil, apperror.NotFound("retur not found")
+	}
+
+	return header, details, nil
+}
+
+func NewReturUsecase(returRepo repository.ReturRepository, transaksiRepo repository.TransaksiRepository) ReturUsecase {
+	return &returUsecase{returRepo: returRepo, transaksiRepo: transaksiRepo}
+}
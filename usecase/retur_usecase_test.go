@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"testing"
+	"time"
+)
+
+// fakeReturRepo records the retur it is sent.
+type fakeReturRepo struct {
+	repository.ReturRepository
+	created bool
+	header  entity.ReturHeader
+}
+
+func (f *fakeReturRepo) CreateRetur(actor entity.User, header entity.ReturHeader, details []entity.ReturDetail) (entity.ReturHeader, []entity.ReturDetail, error) {
+	f.created = true
+	f.header = header
+	return header, details, nil
+}
+
+func TestCreateReturInvalid(t *testing.T) {
+	line := []entity.ReturDetail{{IDTransDetail: "D-1", Qty: 1}}
+	tests := []struct {
+		name    string
+		alasan  string
+		details []entity.ReturDetail
+	}{
+		{"no lines", "rusak", nil},
+		{"no alasan", "  ", line},
+		{"no id_trans_detail", "rusak", []entity.ReturDetail{{Qty: 1}}},
+		{"zero qty", "rusak", []entity.ReturDetail{{IDTransDetail: "D-1"}}},
+		{"negative qty", "rusak", []entity.ReturDetail{{IDTransDetail: "D-1", Qty: 2}, {IDTransDetail: "D-2", Qty: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			returRepo := &fakeReturRepo{}
+			uc := NewReturUsecase(returRepo, nil)
+
+			_, _, err := uc.CreateRetur(entity.User{}, "TR-1", entity.ReturHeader{Alasan: tt.alasan}, tt.details)
+			if !apperror.Is(err, apperror.CodeValidation) {
+				t.Errorf("error = %v, want a validation error", err)
+			}
+			if returRepo.created {
+				t.Error("the retur was created")
+			}
+		})
+	}
+}
+
+func TestCreateReturTanggal(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		name string
+		tgl  time.Time
+		want time.Time
+	}{
+		{"given in local time", time.Date(2024, 12, 8, 6, 30, 0, 0, wib), time.Date(2024, 12, 7, 23, 30, 0, 0, time.UTC)},
+		{"given in UTC", time.Date(2024, 12, 8, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 8, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			returRepo := &fakeReturRepo{}
+			header := entity.ReturHeader{Alasan: "rusak", TglRetur: tt.tgl}
+			if _, _, err := NewReturUsecase(returRepo, nil).CreateRetur(entity.User{}, "TR-1", header, []entity.ReturDetail{{IDTransDetail: "D-1", Qty: 1}}); err != nil {
+				t.Fatalf("CreateRetur returned error %v", err)
+			}
+			if got := returRepo.header.TglRetur; !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("tgl_retur = %s, want %s", got, tt.want)
+			}
+			if returRepo.header.IDTrans != "TR-1" {
+				t.Errorf("id_trans = %q, want TR-1", returRepo.header.IDTrans)
+			}
+		})
+	}
+
+	returRepo := &fakeReturRepo{}
+	before := time.Now()
+	if _, _, err := NewReturUsecase(returRepo, nil).CreateRetur(entity.User{}, "TR-1", entity.ReturHeader{Alasan: "rusak"}, []entity.ReturDetail{{IDTransDetail: "D-1", Qty: 1}}); err != nil {
+		t.Fatalf("CreateRetur returned error %v", err)
+	}
+	if got := returRepo.header.TglRetur; got.Before(before.Add(-time.Second)) || got.Location() != time.UTC {
+		t.Errorf("tgl_retur = %s, want now in UTC", got)
+	}
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"strings"
+)
+
+type ShiftUsecase interface {
+	// Every kasir has their own shift, and only they can work with it. A
+	// supervisor may act on any shift.
+	Open(actor entity.User, shift entity.Shift) (entity.Shift, error)
+	GetOpen(actor entity.User, kasir string) (entity.Shift, error)
+	Close(actor entity.User, idShift string, kasDihitung money.Money) (entity.ZReport, error)
+	ZReport(actor entity.User, idShift string) (entity.ZReport, error)
+}
+
+type shiftUsecase struct {
+	shiftRepo repository.ShiftRepository
+}
+
+func (s *shiftUsecase) Open(actor entity.User, shift entity.Shift) (entity.Shift, error) {
+	if strings.TrimSpace(shift.Kasir) == "" {
+		return entity.Shift{}, apperror.Invalid("kasir", "kasir tidak boleh kosong")
+	}
+	if err := checkShiftKasir(actor, shift.Kasir); err != nil {
+		return entity.Shift{}, err
+	}
+	if shift.SaldoAwal < 0 {
+		return entity.Shift{}, apperror.Invalid("saldo_awal", "saldo awal tidak boleh minus")
+	}
+
+	return s.shiftRepo.Open(shift)
+}
+
+func (s *shiftUsecase) GetOpen(actor entity.User, kasir string) (entity.Shift, error) {
+	if err := checkShiftKasir(actor, kasir); err != nil {
+		return entity.Shift{}, err
+	}
+	return s.shiftRepo.GetOpen(kasir)
+}
+
+func (s *shiftUsecase) Close(actor entity.User, idShift string, kasDihitung money.Money) (entity.ZReport, error) {
+	if kasDihitung < 0 {
+		return entity.ZReport{}, apperror.Invalid("kas_dihitung", "kas dihitung tidak boleh minus")
+	}
+	shift, err := s.shiftRepo.GetByID(idShift)
+	if err != nil {
+		return entity.ZReport{}, err
+	}
+	if err := checkShiftKasir(actor, shift.Kasir); err != nil {
+		return entity.ZReport{}, err
+	}
+
+	if _, err := s.shiftRepo.Close(idShift, kasDihitung); err != nil {
+		return entity.ZReport{}, err
+	}
+
+	return s.shiftRepo.ZReport(idShift)
+}
+
+func (s *shiftUsecase) ZReport(actor entity.User, idShift string) (entity.ZReport, error) {
+	shift, err := s.shiftRepo.GetByID(idShift)
+	if err != nil {
+		return entity.ZReport{}, err
+	}
+	if err := checkShiftKasir(actor, shift.Kasir); err != nil {
+		return entity.ZReport{}, err
+	}
+
+	return s.shiftRepo.ZReport(idShift)
+}
+
+// checkShiftKasir lets a kasir only handle their own shift.
+func checkShiftKasir(actor entity.User, kasir string) error {
+	if actor.Username != kasir && !actor.HasRole(entity.RoleSupervisor) {
+		return apperror.Forbidden("shift milik kasir %s", kasir)
+	}
+	return nil
+}
+
+func NewShiftUsecase(shiftRepo repository.ShiftRepository) ShiftUsecase {
+	return &shiftUsecase{shiftRepo: shiftRepo}
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"fmt"
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"testing"
+)
+
+// fakeShiftRepo keeps shifts in memory and, like ux_kasir_shift_open, allows
+// one open shift per kasir.
+type fakeShiftRepo struct {
+	shifts []entity.Shift
+}
+
+func (f *fakeShiftRepo) Open(shift entity.Shift) (entity.Shift, error) {
+	if _, err := f.GetOpen(shift.Kasir); err == nil {
+		return entity.Shift{}, apperror.Conflict("kasir %s masih punya shift yang terbuka", shift.Kasir)
+	}
+	shift.IDShift = fmt.Sprintf("SH-%d", len(f.shifts)+1)
+	shift.Status = entity.ShiftOpen
+	f.shifts = append(f.shifts, shift)
+	return shift, nil
+}
+
+func (f *fakeShiftRepo) GetOpen(kasir string) (entity.Shift, error) {
+	for _, shift := range f.shifts {
+		if shift.Kasir == kasir && shift.Status == entity.ShiftOpen {
+			return shift, nil
+		}
+	}
+	return entity.Shift{}, apperror.NotFound("open shift of kasir %s not found", kasir)
+}
+
+func (f *fakeShiftRepo) GetByID(idShift string) (entity.Shift, error) {
+	for _, shift := range f.shifts {
+		if shift.IDShift == idShift {
+			return shift, nil
+		}
+	}
+	return entity.Shift{}, apperror.NotFound("shift not found")
+}
+
+func (f *fakeShiftRepo) Close(idShift string, kasDihitung money.Money) (entity.Shift, error) {
+	for i := range f.shifts {
+		if f.shifts[i].IDShift != idShift {
+			continue
+		}
+		if f.shifts[i].Status != entity.ShiftOpen {
+			return entity.Shift{}, apperror.Conflict("shift %s sudah ditutup", idShift)
+		}
+		f.shifts[i].Status = entity.ShiftClosed
+		f.shifts[i].KasDihitung = kasDihitung
+		return f.shifts[i], nil
+	}
+	return entity.Shift{}, apperror.NotFound("shift not found")
+}
+
+func (f *fakeShiftRepo) ZReport(idShift string) (entity.ZReport, error) {
+	shift, err := f.GetByID(idShift)
+	return entity.ZReport{Shift: shift, KasDihitung: shift.KasDihitung}, err
+}
+
+func TestShiftLock(t *testing.T) {
+	ani := entity.User{Username: "ani", Role: entity.RoleKasir}
+	budi := entity.User{Username: "budi", Role: entity.RoleKasir}
+	spv := entity.User{Username: "sari", Role: entity.RoleSupervisor}
+
+	type step struct {
+		name string
+		do   func(uc ShiftUsecase) error
+		code apperror.Code // empty when the step succeeds
+	}
+	open := func(actor entity.User, kasir string, saldo money.Money) func(ShiftUsecase) error {
+		return func(uc ShiftUsecase) error {
+			_, err := uc.Open(actor, entity.Shift{Kasir: kasir, SaldoAwal: saldo})
+			return err
+		}
+	}
+	closeShift := func(actor entity.User, idShift string, kas money.Money) func(ShiftUsecase) error {
+		return func(uc ShiftUsecase) error {
+			_, err := uc.Close(actor, idShift, kas)
+			return err
+		}
+	}
+	getOpen := func(actor entity.User, kasir string) func(ShiftUsecase) error {
+		return func(uc ShiftUsecase) error {
+			_, err := uc.GetOpen(actor, kasir)
+			return err
+		}
+	}
+	zReport := func(actor entity.User, idShift string) func(ShiftUsecase) error {
+		return func(uc ShiftUsecase) error {
+			_, err := uc.ZReport(actor, idShift)
+			return err
+		}
+	}
+
+	steps := []step{
+		{"ani opens a shift", open(ani, "ani", money.New(200000)), ""},
+		{"ani cannot open a second shift", open(ani, "ani", money.New(200000)), apperror.CodeConflict},
+		{"budi opens a shift alongside", open(budi, "budi", 0), ""},
+		{"budi cannot open a shift for ani", open(budi, "ani", 0), apperror.CodeForbidden},
+		{"no kasir", open(spv, " ", 0), apperror.CodeValidation},
+		{"negative saldo awal", open(spv, "citra", money.New(-1)), apperror.CodeValidation},
+		{"a supervisor opens a shift for citra", open(spv, "citra", 0), ""},
+		{"ani finds the open shift", getOpen(ani, "ani"), ""},
+		{"ani cannot look at budi's shift", getOpen(ani, "budi"), apperror.CodeForbidden},
+		{"a supervisor looks at budi's shift", getOpen(spv, "budi"), ""},
+		{"budi cannot close ani's shift", closeShift(budi, "SH-1", money.New(200000)), apperror.CodeForbidden},
+		{"budi cannot read ani's z report", zReport(budi, "SH-1"), apperror.CodeForbidden},
+		{"negative kas dihitung", closeShift(ani, "SH-1", money.New(-1)), apperror.CodeValidation},
+		{"ani closes the shift", closeShift(ani, "SH-1", money.New(250000)), ""},
+		{"a closed shift stays closed", closeShift(ani, "SH-1", money.New(250000)), apperror.CodeConflict},
+		{"ani has no open shift now", getOpen(ani, "ani"), apperror.CodeNotFound},
+		{"ani opens the next shift", open(ani, "ani", money.New(250000)), ""},
+		{"a supervisor closes budi's shift", closeShift(spv, "SH-2", 0), ""},
+		{"unknown shift", closeShift(spv, "SH-9", 0), apperror.CodeNotFound},
+	}
+
+	uc := NewShiftUsecase(&fakeShiftRepo{})
+	for _, s := range steps {
+		err := s.do(uc)
+		if s.code == "" && err != nil {
+			t.Errorf("%s: returned error %v", s.name, err)
+		}
+		if s.code != "" && !apperror.Is(err, s.code) {
+			t.Errorf("%s: error = %v, want %s", s.name, err, s.code)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"roxy/repository"
+	"roxy/shared/notifier"
+	"time"
+)
+
+// alerts that keep failing are given up on after this many attempts
+const maxAlertAttempts = 5
+
+type StockAlertUsecase interface {
+	DispatchPending() (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type stockAlertUsecase struct {
+	alertRepo repository.StockAlertRepository
+	notifier  notifier.Notifier
+}
+
+// DispatchPending sends the queued alerts and returns how many went out. A
+// failed alert stays queued for the next run.
+func (s *stockAlertUsecase) DispatchPending() (int, error) {
+	alerts, err := s.alertRepo.ListPending(maxAlertAttempts, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, alert := range alerts {
+		if err := s.notifier.Notify(alert); err != nil {
+			if err := s.alertRepo.MarkFailed(alert.IDAlert, err.Error()); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if err := s.alertRepo.MarkSent(alert.IDAlert); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Run dispatches pending alerts every interval until ctx is done.
+func (s *stockAlertUsecase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DispatchPending(); err != nil {
+			log.Printf("stock alert dispatch failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func NewStockAlertUsecase(alertRepo repository.StockAlertRepository, notifier notifier.Notifier) StockAlertUsecase {
+	return &stockAlertUsecase{alertRepo: alertRepo, notifier: notifier}
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+)
+
+type StockMovementUseCase interface {
+	Create(movement entity.StockMovement) (entity.StockMovement, error)
+	ListByBarang(idBarang string) ([]entity.StockMovement, error)
+}
+
+type stockMovementUseCase struct {
+	stockRepo  repository.StockMovementRepository
+	barangRepo repository.MstBarangRepository
+}
+
+// Create books a manual movement. Sale, purchase and return movements are
+// generated by their own documents and cannot be posted here.
+func (s *stockMovementUseCase) Create(movement entity.StockMovement) (entity.StockMovement, error) {
+	if movement.Tipe != entity.MovementAdjustment && movement.Tipe != entity.MovementTransfer {
+		return entity.StockMovement{}, apperror.Invalid("tipe", "tipe must be %s or %s", entity.MovementAdjustment, entity.MovementTransfer)
+	}
+	if movement.Qty == 0 {
+		return entity.StockMovement{}, apperror.Invalid("qty", "qty cannot be zero")
+	}
+
+	if _, err := s.barangRepo.GetByID(movement.IDBarang); err != nil {
+		return entity.StockMovement{}, err
+	}
+
+	return s.stockRepo.Create(movement)
+}
+
+func (s *stockMovementUseCase) ListByBarang(idBarang string) ([]entity.StockMovement, error) {
+	if _, err := s.barangRepo.GetByID(idBarang); err != nil {
+		return nil, err
+	}
+
+	return s.stockRepo.ListByBarang(idBarang)
+}
+
+func NewStockMovementUseCase(stockRepo repository.StockMovementRepository, barangRepo repository.MstBarangRepository) StockMovementUseCase {
+	return &stockMovementUseCase{stockRepo: stockRepo, barangRepo: barangRepo}
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"slices"
+	"strings"
+	"time"
+)
+
+type StockOpnameUsecase interface {
+	CreateStockOpname(catatan string, idBarang []string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error)
+	ListStockOpname() ([]entity.StockOpnameHeader, error)
+	GetStockOpnameByID(idOpname string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error)
+	RecordCounts(idOpname string, counts []entity.StockOpnameCount) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error)
+	ApproveStockOpname(idOpname string, kodeAlasan string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error)
+	CancelStockOpname(idOpname string) (entity.StockOpnameHeader, error)
+}
+
+type stockOpnameUsecase struct {
+	opnameRepo repository.StockOpnameRepository
+	barangRepo repository.MstBarangRepository
+}
+
+// CreateStockOpname opens a count for the given barang, or for every barang
+// when idBarang is empty.
+func (s *stockOpnameUsecase) CreateStockOpname(catatan string, idBarang []string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error) {
+	seen := make(map[string]bool, len(idBarang))
+	for _, id := range idBarang {
+		if seen[id] {
+			return entity.StockOpnameHeader{}, nil, apperror.Validation("barang %s disebut lebih dari sekali", id)
+		}
+		seen[id] = true
+
+		if _, err := s.barangRepo.GetByID(id); err != nil {
+			return entity.StockOpnameHeader{}, nil, err
+		}
+	}
+
+	header := entity.StockOpnameHeader{
+		TglOpname: time.Now().UTC(),
+		Status:    entity.OpnameOpen,
+		Catatan:   catatan,
+	}
+
+	return s.opnameRepo.CreateStockOpname(header, idBarang)
+}
+
+func (s *stockOpnameUsecase) ListStockOpname() ([]entity.StockOpnameHeader, error) {
+	return s.opnameRepo.ListStockOpname()
+}
+
+func (s *stockOpnameUsecase) GetStockOpnameByID(idOpname string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error) {
+	return s.opnameRepo.GetStockOpnameByID(idOpname)
+}
+
+func (s *stockOpnameUsecase) RecordCounts(idOpname string, counts []entity.StockOpnameCount) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error) {
+	if len(counts) == 0 {
+		return entity.StockOpnameHeader{}, nil, apperror.Invalid("counts", "hasil hitung tidak boleh kosong")
+	}
+
+	seen := make(map[string]bool, len(counts))
+	for _, count := range counts {
+		if count.IDBarang == "" {
+			return entity.StockOpnameHeader{}, nil, apperror.Invalid("id_barang", "id_barang harus diisi")
+		}
+		if count.QtyHitung < 0 {
+			return entity.StockOpnameHeader{}, nil, apperror.Invalid("qty_hitung", "qty_hitung barang %s tidak boleh minus", count.IDBarang)
+		}
+		if seen[count.IDBarang] {
+			return entity.StockOpnameHeader{}, nil, apperror.Validation("barang %s dihitung lebih dari sekali", count.IDBarang)
+		}
+		seen[count.IDBarang] = true
+	}
+
+	if err := s.opnameRepo.RecordCounts(idOpname, counts); err != nil {
+		return entity.StockOpnameHeader{}, nil, err
+	}
+
+	return s.opnameRepo.GetStockOpnameByID(idOpname)
+}
+
+func (s *stockOpnameUsecase) ApproveStockOpname(idOpname string, kodeAlasan string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error) {
+	if !slices.Contains(entity.OpnameReasons, kodeAlasan) {
+		return entity.StockOpnameHeader{}, nil, apperror.Invalid("kode_alasan", "kode_alasan must be one of %s", strings.Join(entity.OpnameReasons, ", "))
+	}
+
+	if err := s.opnameRepo.ApproveStockOpname(idOpname, kodeAlasan); err != nil {
+		return entity.StockOpnameHeader{}, nil, err
+	}
+
+	return s.opnameRepo.GetStockOpnameByID(idOpname)
+}
+
+func (s *stockOpnameUsecase) CancelStockOpname(idOpname string) (entity.StockOpnameHeader, error) {
+	if err := s.opnameRepo.CancelStockOpname(idOpname); err != nil {
+		return entity.StockOpnameHeader{}, err
+	}
+
+	header, _, err := s.opnameRepo.GetStockOpnameByID(idOpname)
+	return header, err
+}
+
+func NewStockOpnameUsecase(opnameRepo repository.StockOpnameRepository, barangRepo repository.MstBarangRepository) StockOpnameUsecase {
+	return &stockOpnameUsecase{opnameRepo: opnameRepo, barangRepo: barangRepo}
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"testing"
+)
+
+// fakeOpnameRepo records what reached the database.
+type fakeOpnameRepo struct {
+	repository.StockOpnameRepository
+	counted  bool
+	approved string
+}
+
+func (f *fakeOpnameRepo) RecordCounts(idOpname string, counts []entity.StockOpnameCount) error {
+	f.counted = true
+	return nil
+}
+
+func (f *fakeOpnameRepo) ApproveStockOpname(idOpname string, kodeAlasan string) error {
+	f.approved = kodeAlasan
+	return nil
+}
+
+func (f *fakeOpnameRepo) GetStockOpnameByID(idOpname string) (entity.StockOpnameHeader, []entity.StockOpnameDetail, error) {
+	return entity.StockOpnameHeader{IDOpname: idOpname, KodeAlasan: f.approved}, nil, nil
+}
+
+func TestApproveStockOpnameReason(t *testing.T) {
+	tests := []struct {
+		kodeAlasan string
+		valid      bool
+	}{
+		{entity.OpnameReasonCount, true},
+		{entity.OpnameReasonDamaged, true},
+		{entity.OpnameReasonLost, true},
+		{entity.OpnameReasonExpired, true},
+		{entity.OpnameReasonCorrection, true},
+		{"", false},
+		{"hilang", false},
+		{"Damaged", false},
+	}
+	for _, tt := range tests {
+		opnameRepo := &fakeOpnameRepo{}
+		header, _, err := NewStockOpnameUsecase(opnameRepo, nil).ApproveStockOpname("OP-1", tt.kodeAlasan)
+		if tt.valid {
+			if err != nil || header.KodeAlasan != tt.kodeAlasan {
+				t.Errorf("ApproveStockOpname(%q) = %+v, %v", tt.kodeAlasan, header, err)
+			}
+			continue
+		}
+		if !apperror.Is(err, apperror.CodeValidation) {
+			t.Errorf("ApproveStockOpname(%q) error = %v, want a validation error", tt.kodeAlasan, err)
+		}
+		if opnameRepo.approved != "" {
+			t.Errorf("ApproveStockOpname(%q) reached the repository", tt.kodeAlasan)
+		}
+	}
+}
+
+func TestRecordCountsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts []entity.StockOpnameCount
+	}{
+		{"no counts", nil},
+		{"no id_barang", []entity.StockOpnameCount{{QtyHitung: 3}}},
+		{"negative qty", []entity.StockOpnameCount{{IDBarang: "BR-1", QtyHitung: -1}}},
+		{"counted twice", []entity.StockOpnameCount{{IDBarang: "BR-1", QtyHitung: 3}, {IDBarang: "BR-1", QtyHitung: 4}}},
+	}
+	for _, tt := range tests {
+		opnameRepo := &fakeOpnameRepo{}
+		_, _, err := NewStockOpnameUsecase(opnameRepo, nil).RecordCounts("OP-1", tt.counts)
+		if !apperror.Is(err, apperror.CodeValidation) {
+			t.Errorf("%s: error = %v, want a validation error", tt.name, err)
+		}
+		if opnameRepo.counted {
+			t.Errorf("%s: the counts were recorded", tt.name)
+		}
+	}
+
+	opnameRepo := &fakeOpnameRepo{}
+	counts := []entity.StockOpnameCount{{IDBarang: "BR-1", QtyHitung: 0}, {IDBarang: "BR-2", QtyHitung: 12}}
+	if _, _, err := NewStockOpnameUsecase(opnameRepo, nil).RecordCounts("OP-1", counts); err != nil || !opnameRepo.counted {
+		t.Errorf("RecordCounts(%v) = %v, counted %v", counts, err, opnameRepo.counted)
+	}
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"fmt"
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"strings"
+)
+
+type SupplierUseCase interface {
+	Create(supplier entity.Supplier) (entity.Supplier, error)
+	List() ([]entity.Supplier, error)
+	GetByID(id string) (entity.Supplier, error)
+	Update(supplier entity.Supplier) (entity.Supplier, error)
+	Delete(id string) error
+}
+
+type supplierUseCase struct {
+	supplierRepository repository.SupplierRepository
+}
+
+func (s *supplierUseCase) Create(supplier entity.Supplier) (entity.Supplier, error) {
+	if strings.TrimSpace(supplier.NmSupplier) == "" {
+		return entity.Supplier{}, apperror.Invalid("nm_supplier", "name cannot be empty")
+	}
+
+	return s.supplierRepository.Create(supplier)
+}
+
+func (s *supplierUseCase) List() ([]entity.Supplier, error) {
+	return s.supplierRepository.List()
+}
+
+func (s *supplierUseCase) GetByID(id string) (entity.Supplier, error) {
+	supplier, err := s.supplierRepository.GetByID(id)
+	if err != nil {
+		return entity.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (s *supplierUseCase) Update(supplier entity.Supplier) (entity.Supplier, error) {
+	payload, err := s.supplierRepository.GetByID(supplier.IDSupplier)
+	if err != nil {
+		return entity.Supplier{}, err
+	}
+
+	if strings.TrimSpace(supplier.NmSupplier) == "" {
+		supplier.NmSupplier = payload.NmSupplier
+	}
+	if strings.TrimSpace(supplier.Alamat) == "" {
+		supplier.Alamat = payload.Alamat
+	}
+	if strings.TrimSpace(supplier.Telepon) == "" {
+		supplier.Telepon = payload.Telepon
+	}
+
+	updatedSupplier, err := s.supplierRepository.Update(supplier)
+	if err != nil {
+		return entity.Supplier{}, fmt.Errorf("failed to update supplier: %w", err)
+	}
+
+	return updatedSupplier, nil
+}
+
+func (s *supplierUseCase) Delete(id string) error {
+	_, err := s.supplierRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.supplierRepository.Delete(id)
+	if err != nil {
+		return fmt.Errorf("failed to delete supplier: %w", err)
+	}
+
+	return nil
+}
+
+func NewSupplierUseCase(supplierRepository repository.SupplierRepository) SupplierUseCase {
+	return &supplierUseCase{supplierRepository: supplierRepository}
+}
@@ -1,53 +1,95 @@
 package usecase
 
 import (
-	"errors"
-	"fmt"
 	"roxy/entity"
 	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/barcode"
+	"roxy/shared/money"
+	"slices"
+	"strings"
+	"time"
 )
 
 type TransaksiUsecase interface {
-	CreateTransaksiWithDetail(transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (string, error)
-	GetAllTransaksi() ([]entity.TransaksiHeader, error)
+	CreateTransaksiWithDetail(actor entity.User, transaksi entity.TransaksiHeader, details []entity.TransaksiDetail, payments []entity.Payment) (string, error)
+	GetAllTransaksi(filter entity.TransaksiFilter) ([]entity.TransaksiHeader, int, error)
 	GetTransaksiByID(idTrans string) (entity.TransaksiHeader, []entity.TransaksiDetail, error)
-	UpdateTransaksiWithDetail(idTrans string, transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error)
-	DeleteTransaksi(idTrans string) error
+	UpdateTransaksiWithDetail(actor entity.User, idTrans string, transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error)
+	GetTransaksiSummary() (entity.TransaksiSummary, error)
+	VoidTransaksi(actor entity.User, idTrans string, reason string) (entity.TransaksiHeader, error)
+	DeleteTransaksi(actor entity.User, idTrans string) error
+	// RecalcTotals checks the totals of every active transaksi against the
+	// sum of its lines, keeping the diskon and pajak it was charged, and when
+	// apply is set corrects those that are off.
+	RecalcTotals(actor entity.User, apply bool) ([]entity.TotalCorrection, error)
 }
 
 type transaksiUsecase struct {
 	TransaksiRepo repository.TransaksiRepository
 	barangRepo    repository.MstBarangRepository
+	pajakPersen   int
 }
 
-func (t *transaksiUsecase) CreateTransaksiWithDetail(transaksi entity.TransaksiHeader, details []entity.TransaksiDetail) (string, error) {
+func (t *transaksiUsecase) CreateTransaksiWithDetail(actor entity.User, transaksi entity.TransaksiHeader, details []entity.TransaksiDetail, payments []entity.Payment) (string, error) {
 	if len(details) == 0 {
-		return "", errors.New("transaksi detail tidak boleh kosong")
+		return "", apperror.Invalid("detail", "transaksi detail tidak boleh kosong")
+	}
+	// a diskon changes the price, which a kasir may not do
+	if transaksi.Diskon != 0 && !actor.HasRole(entity.RoleSupervisor) {
+		return "", apperror.Forbidden("diskon hanya boleh diberikan oleh %s", entity.RoleSupervisor)
 	}
 
-	var total float64
+	var total money.Money
 	for i := range details {
 		if details[i].Qty <= 0 {
-			return "", errors.New("qty harus lebih dari 0")
+			return "", apperror.Invalid("detail.qty", "qty harus lebih dari 0")
+		}
+		if err := t.resolveBarcode(&details[i]); err != nil {
+			return "", err
 		}
 
 		barang, err := t.barangRepo.GetByID(details[i].IDBarang)
 		if err != nil {
-			return "", fmt.Errorf("gagal mendapatkan data barang dengan ID %s: %v", details[i].IDBarang, err)
+			return "", err
 		}
 
-		details[i].Harga = float64(barang.Harga)
+		details[i].Harga = barang.Harga
 
-		details[i].Subtotal = details[i].Harga * float64(details[i].Qty)
+		details[i].Subtotal = details[i].Harga.Mul(details[i].Qty)
 
 		total += details[i].Subtotal
 	}
 
-	transaksi.Total = total
+	if err := t.applyDiskonPajak(&transaksi, total); err != nil {
+		return "", err
+	}
+	total = transaksi.Total
 
 	transaksi.IDTrans = ""
+	// tgl_trans is the moment of the sale in UTC, reports convert it to
+	// local time
+	if transaksi.TglTrans.IsZero() {
+		transaksi.TglTrans = time.Now()
+	}
+	transaksi.TglTrans = transaksi.TglTrans.UTC()
+
+	// payments sent with the sale have to settle it, partial payments go
+	// through AddPayments afterwards
+	if len(payments) > 0 {
+		if err := validatePayments(payments); err != nil {
+			return "", err
+		}
+		var paid money.Money
+		for _, payment := range payments {
+			paid += payment.Jumlah
+		}
+		if paid < total {
+			return "", apperror.Invalid("payments", "pembayaran %s kurang dari total %s", paid, total)
+		}
+	}
 
-	idTransaksi, err := t.TransaksiRepo.CreateTransaksiWithDetail(transaksi, details)
+	idTransaksi, err := t.TransaksiRepo.CreateTransaksiWithDetail(actor, transaksi, details, payments)
 	if err != nil {
 		return "", err
 	}
@@ -55,8 +97,30 @@ func (t *transaksiUsecase) CreateTransaksiWithDetail(transaksi entity.TransaksiH
 	return idTransaksi, nil
 }
 
-func (t *transaksiUsecase) GetAllTransaksi() ([]entity.TransaksiHeader, error) {
-	return t.TransaksiRepo.GetAllTransaksi()
+// GetAllTransaksi sorts by tgl_trans unless told otherwise.
+func (t *transaksiUsecase) GetAllTransaksi(filter entity.TransaksiFilter) ([]entity.TransaksiHeader, int, error) {
+	if err := normalizePage(&filter.Page); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = "tgl_trans"
+	}
+	if !slices.Contains(entity.TransaksiSortColumns, filter.SortBy) {
+		return nil, 0, apperror.Invalid("sort", "sort must be one of %s", strings.Join(entity.TransaksiSortColumns, ", "))
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, 0, apperror.Invalid("to", "tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+	if filter.MinTotal != nil && filter.MaxTotal != nil && *filter.MaxTotal < *filter.MinTotal {
+		return nil, 0, apperror.Invalid("max_total", "max_total cannot be less than min_total")
+	}
+	if filter.Status != "" && filter.Status != entity.TransaksiActive && filter.Status != entity.TransaksiVoided {
+		return nil, 0, apperror.Invalid("status", "status must be one of %s, %s", entity.TransaksiActive, entity.TransaksiVoided)
+	}
+
+	return t.TransaksiRepo.GetAllTransaksi(filter)
 }
 
 func (t *transaksiUsecase) GetTransaksiByID(idTrans string) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
@@ -68,58 +132,205 @@ func (t *transaksiUsecase) GetTransaksiByID(idTrans string) (entity.TransaksiHea
 	return transaksi, details, nil
 }
 
-func (t *transaksiUsecase) UpdateTransaksiWithDetail(idTrans string, header entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
-	oldTransaksi, _, err := t.TransaksiRepo.GetTransaksiByID(idTrans)
+func (t *transaksiUsecase) UpdateTransaksiWithDetail(actor entity.User, idTrans string, header entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
+	oldTransaksi, oldDetails, err := t.TransaksiRepo.GetTransaksiByID(idTrans)
 	if err != nil {
-		return header, details, fmt.Errorf("Message: %s, ID transaksi: %s", err.Error(), header.IDTrans)
+		return header, details, err
 	}
 
+	if len(details) == 0 {
+		return header, details, apperror.Invalid("detail", "transaksi detail tidak boleh kosong")
+	}
+
+	header.IDTrans = idTrans
 	if header.TglTrans.IsZero() {
 		header.TglTrans = oldTransaksi.TglTrans
 	}
+	header.TglTrans = header.TglTrans.UTC()
 
-	var total float64
+	existing := make(map[string]entity.TransaksiDetail, len(oldDetails))
+	for _, old := range oldDetails {
+		existing[old.IDTransDetail] = old
+	}
+
+	var total money.Money
 	for i := range details {
 		if details[i].Qty <= 0 {
-			return header, details, fmt.Errorf("qty harus lebih dari 0")
+			return header, details, apperror.Invalid("detail.qty", "qty harus lebih dari 0")
+		}
+		if err := t.resolveBarcode(&details[i]); err != nil {
+			return header, details, err
 		}
 
-		barang, err := t.barangRepo.GetByID(details[i].IDBarang)
-		if err != nil {
-			return header, details, fmt.Errorf("gagal mendapatkan data barang dengan ID %s: %v", details[i].IDBarang, err)
+		// a line that keeps its barang keeps the harga it was sold at
+		old, ok := existing[details[i].IDTransDetail]
+		if ok && old.IDBarang == details[i].IDBarang {
+			details[i].Harga = old.Harga
+		} else {
+			barang, err := t.barangRepo.GetByID(details[i].IDBarang)
+			if err != nil {
+				return header, details, err
+			}
+			details[i].Harga = barang.Harga
 		}
 
-		details[i].Subtotal = float64(barang.Harga) * float64(details[i].Qty)
+		details[i].Subtotal = details[i].Harga.Mul(details[i].Qty)
 		total += details[i].Subtotal
 	}
 
-	header.Total = total
+	if err := t.applyDiskonPajak(&header, total); err != nil {
+		return header, details, err
+	}
 
-	header, details, err = t.TransaksiRepo.UpdateTransaksiWithDetail(header, details)
+	header, details, err = t.TransaksiRepo.UpdateTransaksiWithDetail(actor, header, details)
 	if err != nil {
 		return header, details, err
 	}
 
-	return header, details, nil
+	return t.TransaksiRepo.GetTransaksiByID(idTrans)
 }
 
-func (t *transaksiUsecase) DeleteTransaksi(idTrans string) error {
+func (t *transaksiUsecase) GetTransaksiSummary() (entity.TransaksiSummary, error) {
+	return t.TransaksiRepo.GetTransaksiSummary()
+}
+
+func (t *transaksiUsecase) VoidTransaksi(actor entity.User, idTrans string, reason string) (entity.TransaksiHeader, error) {
+	if strings.TrimSpace(reason) == "" {
+		return entity.TransaksiHeader{}, apperror.Invalid("reason", "alasan void tidak boleh kosong")
+	}
+
+	return t.TransaksiRepo.VoidTransaksi(actor, idTrans, reason)
+}
+
+func (t *transaksiUsecase) DeleteTransaksi(actor entity.User, idTrans string) error {
 	_, _, err := t.TransaksiRepo.GetTransaksiByID(idTrans)
 	if err != nil {
-		return fmt.Errorf("transaksi dengan id %s tidak ditemukan", idTrans)
+		return apperror.NotFound("transaksi dengan id %s tidak ditemukan", idTrans)
+	}
+
+	err = t.TransaksiRepo.DeleteTransaksi(actor, idTrans)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t *transaksiUsecase) RecalcTotals(actor entity.User, apply bool) ([]entity.TotalCorrection, error) {
+	corrections := []entity.TotalCorrection{}
+	filter := entity.TransaksiFilter{
+		Page:   entity.Page{Page: 1, Limit: entity.MaxPageLimit},
+		Status: entity.TransaksiActive,
+		SortBy: "id_trans",
 	}
 
-	err = t.TransaksiRepo.DeleteTransaksi(idTrans)
+	for {
+		transaksis, total, err := t.TransaksiRepo.GetAllTransaksi(filter)
+		if err != nil {
+			return corrections, err
+		}
+
+		for _, transaksi := range transaksis {
+			header, details, err := t.TransaksiRepo.GetTransaksiByID(transaksi.IDTrans)
+			if err != nil {
+				return corrections, err
+			}
+			if !resumLines(&header, details) {
+				continue
+			}
+
+			before, after, err := t.TransaksiRepo.RecalcTotals(actor, header, details, apply)
+			if apperror.Is(err, apperror.CodeConflict) {
+				// voided or edited since it was read, an edit already
+				// computes the totals
+				continue
+			}
+			if err != nil {
+				return corrections, err
+			}
+			if before.Total != after.Total || before.StatusBayar != after.StatusBayar {
+				corrections = append(corrections, entity.TotalCorrection{
+					IDTrans:         transaksi.IDTrans,
+					TotalLama:       before.Total,
+					TotalBaru:       after.Total,
+					StatusBayarLama: before.StatusBayar,
+					StatusBayarBaru: after.StatusBayar,
+				})
+			}
+		}
+
+		if filter.Page.Page*filter.Limit >= total {
+			return corrections, nil
+		}
+		filter.Page.Page++
+	}
+}
+
+// resumLines recomputes the line subtotals and the total of a transaksi and
+// reports whether anything differs from what was stored. The diskon and pajak
+// stay as they were charged, the pajak rate may have changed since.
+func resumLines(header *entity.TransaksiHeader, details []entity.TransaksiDetail) bool {
+	changed := false
+	var subtotal money.Money
+	for i := range details {
+		lineSubtotal := details[i].Harga.Mul(details[i].Qty)
+		if details[i].Subtotal != lineSubtotal {
+			details[i].Subtotal = lineSubtotal
+			changed = true
+		}
+		subtotal += lineSubtotal
+	}
+
+	total := subtotal - header.Diskon + header.Pajak
+	if header.Total != total {
+		header.Total = total
+		changed = true
+	}
+	return changed
+}
+
+// applyDiskonPajak sets the total of a transaksi from the sum of its lines:
+// the diskon is taken off first and pajak is charged on what remains.
+func (t *transaksiUsecase) applyDiskonPajak(header *entity.TransaksiHeader, subtotal money.Money) error {
+	if header.Diskon < 0 {
+		return apperror.Invalid("header.diskon", "diskon tidak boleh minus")
+	}
+	if header.Diskon > subtotal {
+		return apperror.Invalid("header.diskon", "diskon %s melebihi subtotal %s", header.Diskon, subtotal)
+	}
+
+	header.Pajak = (subtotal - header.Diskon).MulRatio(int64(t.pajakPersen), 100)
+	header.Total = subtotal - header.Diskon + header.Pajak
+	return nil
+}
+
+// resolveBarcode fills in the id_barang of a line that was scanned by
+// barcode instead.
+func (t *transaksiUsecase) resolveBarcode(detail *entity.TransaksiDetail) error {
+	if detail.Barcode == "" {
+		return nil
+	}
+
+	code, err := barcode.Normalize(detail.Barcode)
 	if err != nil {
 		return err
 	}
+	barang, err := t.barangRepo.GetByBarcode(code)
+	if err != nil {
+		return err
+	}
+	if detail.IDBarang != "" && detail.IDBarang != barang.Id_barang {
+		return apperror.Validation("barcode %s bukan milik barang %s", detail.Barcode, detail.IDBarang)
+	}
 
+	detail.IDBarang = barang.Id_barang
 	return nil
 }
 
-func NewTransaksiUsecase(transaksiRepo repository.TransaksiRepository, barangRepo repository.MstBarangRepository) TransaksiUsecase {
+func NewTransaksiUsecase(transaksiRepo repository.TransaksiRepository, barangRepo repository.MstBarangRepository, pajakPersen int) TransaksiUsecase {
 	return &transaksiUsecase{
 		TransaksiRepo: transaksiRepo,
 		barangRepo:    barangRepo,
+		pajakPersen:   pajakPersen,
 	}
 }
@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"roxy/shared/money"
+	"testing"
+	"time"
+)
+
+// fakeBarangRepo serves barang by id and by barcode. Methods a test does
+// not expect panic on the nil interface.
+type fakeBarangRepo struct {
+	repository.MstBarangRepository
+	barang map[string]entity.Barang
+}
+
+func (f *fakeBarangRepo) GetByID(id string) (entity.Barang, error) {
+	barang, ok := f.barang[id]
+	if !ok {
+		return entity.Barang{}, apperror.NotFound("barang with ID %s not found", id)
+	}
+	return barang, nil
+}
+
+func (f *fakeBarangRepo) GetByBarcode(code string) (entity.Barang, error) {
+	for _, barang := range f.barang {
+		for _, barcode := range barang.Barcodes {
+			if barcode == code {
+				return barang, nil
+			}
+		}
+	}
+	return entity.Barang{}, apperror.NotFound("barcode %s not found", code)
+}
+
+// fakeTransaksiRepo holds one stored transaksi and records the create or
+// update it is sent.
+type fakeTransaksiRepo struct {
+	repository.TransaksiRepository
+	header  entity.TransaksiHeader
+	details []entity.TransaksiDetail
+
+	updated        bool
+	updatedHeader  entity.TransaksiHeader
+	updatedDetails []entity.TransaksiDetail
+	created        bool
+}
+
+func (f *fakeTransaksiRepo) CreateTransaksiWithDetail(actor entity.User, header entity.TransaksiHeader, details []entity.TransaksiDetail, payments []entity.Payment) (string, error) {
+	f.created = true
+	f.header = header
+	f.header.IDTrans = "TR-NEW"
+	f.details = details
+	return f.header.IDTrans, nil
+}
+
+func (f *fakeTransaksiRepo) GetTransaksiByID(idTrans string) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
+	if idTrans != f.header.IDTrans {
+		return entity.TransaksiHeader{}, nil, apperror.NotFound("transaksi not found")
+	}
+	return f.header, append([]entity.TransaksiDetail(nil), f.details...), nil
+}
+
+func (f *fakeTransaksiRepo) UpdateTransaksiWithDetail(actor entity.User, header entity.TransaksiHeader, details []entity.TransaksiDetail) (entity.TransaksiHeader, []entity.TransaksiDetail, error) {
+	f.updated = true
+	f.updatedHeader = header
+	f.updatedDetails = append([]entity.TransaksiDetail(nil), details...)
+	return header, details, nil
+}
+
+func TestResumLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    entity.TransaksiHeader
+		subtotals []money.Money
+		wantTotal money.Money
+		changed   bool
+	}{
+		{
+			name:      "adds up",
+			header:    entity.TransaksiHeader{Total: money.New(30000)},
+			subtotals: []money.Money{money.New(20000), money.New(10000)},
+			wantTotal: money.New(30000),
+		},
+		{
+			// charged 10% pajak back when the rate was 10%, whatever it
+			// is configured to now
+			name:      "adds up with old pajak",
+			header:    entity.TransaksiHeader{Diskon: money.New(5000), Pajak: money.New(2500), Total: money.New(27500)},
+			subtotals: []money.Money{money.New(20000), money.New(10000)},
+			wantTotal: money.New(27500),
+		},
+		{
+			name:      "total off",
+			header:    entity.TransaksiHeader{Diskon: money.New(5000), Pajak: money.New(2500), Total: money.New(30000)},
+			subtotals: []money.Money{money.New(20000), money.New(10000)},
+			wantTotal: money.New(27500),
+			changed:   true,
+		},
+		{
+			name:      "line subtotal off",
+			header:    entity.TransaksiHeader{Pajak: money.New(3300), Total: money.New(33300)},
+			subtotals: []money.Money{money.New(25000), money.New(10000)},
+			wantTotal: money.New(33300),
+			changed:   true,
+		},
+		{
+			name:      "line subtotal and total off",
+			header:    entity.TransaksiHeader{Pajak: money.New(3300), Total: money.New(38300)},
+			subtotals: []money.Money{money.New(25000), money.New(10000)},
+			wantTotal: money.New(33300),
+			changed:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the lines are 2 x 10000 and 1 x 10000
+			details := []entity.TransaksiDetail{
+				{Harga: money.New(10000), Qty: 2, Subtotal: tt.subtotals[0]},
+				{Harga: money.New(10000), Qty: 1, Subtotal: tt.subtotals[1]},
+			}
+			header := tt.header
+
+			changed := resumLines(&header, details)
+			if changed != tt.changed {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			if header.Total != tt.wantTotal {
+				t.Errorf("total = %s, want %s", header.Total, tt.wantTotal)
+			}
+			if header.Diskon != tt.header.Diskon || header.Pajak != tt.header.Pajak {
+				t.Errorf("diskon, pajak = %s, %s, want them kept at %s, %s", header.Diskon, header.Pajak, tt.header.Diskon, tt.header.Pajak)
+			}
+			if details[0].Subtotal != money.New(20000) || details[1].Subtotal != money.New(10000) {
+				t.Errorf("line subtotals = %s, %s, want 20000.00, 10000.00", details[0].Subtotal, details[1].Subtotal)
+			}
+		})
+	}
+}
+
+func TestApplyDiskonPajak(t *testing.T) {
+	tests := []struct {
+		pajakPersen int
+		subtotal    money.Money
+		diskon      money.Money
+		pajak       money.Money
+		total       money.Money
+	}{
+		{0, money.New(50000), 0, 0, money.New(50000)},
+		{11, money.New(50000), 0, money.New(5500), money.New(55500)},
+		{11, money.New(50000), money.New(10000), money.New(4400), money.New(44400)},
+		{11, money.New(50000), money.New(50000), 0, 0},
+		{11, 1050, 0, 116, 1166}, // 115.5 sen rounds up
+		{10, 1005, 1000, 1, 6},   // pajak on what is left after diskon
+		{11, money.New(12345), 5, 135794, 1370289},
+	}
+	for _, tt := range tests {
+		uc := &transaksiUsecase{pajakPersen: tt.pajakPersen}
+		header := entity.TransaksiHeader{Diskon: tt.diskon}
+		if err := uc.applyDiskonPajak(&header, tt.subtotal); err != nil {
+			t.Errorf("applyDiskonPajak(%s, diskon %s) returned error %v", tt.subtotal, tt.diskon, err)
+			continue
+		}
+		if header.Pajak != tt.pajak || header.Total != tt.total {
+			t.Errorf("applyDiskonPajak(%s, diskon %s) at %d%% = pajak %s total %s, want pajak %s total %s",
+				tt.subtotal, tt.diskon, tt.pajakPersen, header.Pajak, header.Total, tt.pajak, tt.total)
+		}
+	}
+}
+
+func TestApplyDiskonPajakInvalid(t *testing.T) {
+	for _, diskon := range []money.Money{-1, money.New(50000) + 1} {
+		uc := &transaksiUsecase{pajakPersen: 11}
+		header := entity.TransaksiHeader{Diskon: diskon}
+		if err := uc.applyDiskonPajak(&header, money.New(50000)); !apperror.Is(err, apperror.CodeValidation) {
+			t.Errorf("applyDiskonPajak with diskon %s error = %v, want a validation error", diskon, err)
+		}
+	}
+}
+
+func TestUpdateTransaksiReconcilesLines(t *testing.T) {
+	tglTrans := time.Date(2024, 12, 7, 3, 4, 5, 0, time.UTC)
+	stored := entity.TransaksiHeader{IDTrans: "TR-1", TglTrans: tglTrans, Total: money.New(35000)}
+	storedDetails := []entity.TransaksiDetail{
+		{IDTransDetail: "D-1", IDTrans: "TR-1", IDBarang: "BR-1", Qty: 2, Harga: money.New(10000), Subtotal: money.New(20000)},
+		{IDTransDetail: "D-2", IDTrans: "TR-1", IDBarang: "BR-2", Qty: 1, Harga: money.New(15000), Subtotal: money.New(15000)},
+	}
+	// the prices went up since the sale
+	barang := map[string]entity.Barang{
+		"BR-1": {Id_barang: "BR-1", Harga: money.New(12000)},
+		"BR-2": {Id_barang: "BR-2", Harga: money.New(17000)},
+		"BR-3": {Id_barang: "BR-3", Harga: money.New(5000), Barcodes: []string{"4006381333931"}},
+	}
+
+	tests := []struct {
+		name    string
+		header  entity.TransaksiHeader
+		details []entity.TransaksiDetail
+		harga   []money.Money
+		total   money.Money
+		pajak   money.Money
+		tgl     time.Time
+	}{
+		{
+			name:    "kept lines keep their harga",
+			details: []entity.TransaksiDetail{{IDTransDetail: "D-1", IDBarang: "BR-1", Qty: 3}, {IDTransDetail: "D-2", IDBarang: "BR-2", Qty: 1}},
+			harga:   []money.Money{money.New(10000), money.New(15000)},
+			total:   money.New(45000),
+			tgl:     tglTrans,
+		},
+		{
+			name:    "a line that changes barang takes the current harga",
+			details: []entity.TransaksiDetail{{IDTransDetail: "D-1", IDBarang: "BR-1", Qty: 2}, {IDTransDetail: "D-2", IDBarang: "BR-1", Qty: 1}},
+			harga:   []money.Money{money.New(10000), money.New(12000)},
+			total:   money.New(32000),
+			tgl:     tglTrans,
+		},
+		{
+			name:    "new and removed lines",
+			details: []entity.TransaksiDetail{{IDTransDetail: "D-2", IDBarang: "BR-2", Qty: 2}, {IDBarang: "BR-2", Qty: 1}},
+			harga:   []money.Money{money.New(15000), money.New(17000)},
+			total:   money.New(47000),
+			tgl:     tglTrans,
+		},
+		{
+			name:    "scanned line",
+			details: []entity.TransaksiDetail{{IDTransDetail: "D-1", IDBarang: "BR-1", Qty: 2}, {Barcode: "4006381333931", Qty: 4}},
+			harga:   []money.Money{money.New(10000), money.New(5000)},
+			total:   money.New(40000),
+			tgl:     tglTrans,
+		},
+		{
+			name:    "diskon and pajak on the new subtotal",
+			header:  entity.TransaksiHeader{Diskon: money.New(5000)},
+			details: []entity.TransaksiDetail{{IDTransDetail: "D-1", IDBarang: "BR-1", Qty: 2}, {IDTransDetail: "D-2", IDBarang: "BR-2", Qty: 1}},
+			harga:   []money.Money{money.New(10000), money.New(15000)},
+			pajak:   money.New(3300),
+			total:   money.New(33300),
+			tgl:     tglTrans,
+		},
+		{
+			name:    "new tanggal in UTC",
+			header:  entity.TransaksiHeader{TglTrans: time.Date(2024, 12, 8, 9, 0, 0, 0, time.FixedZone("WIB", 7*3600))},
+			details: []entity.TransaksiDetail{{IDTransDetail: "D-1", IDBarang: "BR-1", Qty: 2}},
+			harga:   []money.Money{money.New(10000)},
+			total:   money.New(20000),
+			tgl:     time.Date(2024, 12, 8, 2, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pajakPersen := 0
+			if tt.pajak != 0 {
+				pajakPersen = 11
+			}
+			transaksiRepo := &fakeTransaksiRepo{header: stored, details: storedDetails}
+			uc := NewTransaksiUsecase(transaksiRepo, &fakeBarangRepo{barang: barang}, pajakPersen)
+
+			if _, _, err := uc.UpdateTransaksiWithDetail(entity.User{}, "TR-1", tt.header, tt.details); err != nil {
+				t.Fatalf("UpdateTransaksiWithDetail returned error %v", err)
+			}
+
+			got := transaksiRepo.updatedHeader
+			if got.IDTrans != "TR-1" || got.Total != tt.total || got.Pajak != tt.pajak || got.Diskon != tt.header.Diskon {
+				t.Errorf("header = %+v, want total %s pajak %s diskon %s", got, tt.total, tt.pajak, tt.header.Diskon)
+			}
+			if !got.TglTrans.Equal(tt.tgl) || got.TglTrans.Location() != time.UTC {
+				t.Errorf("tgl_trans = %s, want %s", got.TglTrans, tt.tgl)
+			}
+			if len(transaksiRepo.updatedDetails) != len(tt.harga) {
+				t.Fatalf("%d lines sent, want %d", len(transaksiRepo.updatedDetails), len(tt.harga))
+			}
+			for i, detail := range transaksiRepo.updatedDetails {
+				if detail.Harga != tt.harga[i] || detail.Subtotal != tt.harga[i].Mul(detail.Qty) {
+					t.Errorf("line %d harga %s subtotal %s, want harga %s", i, detail.Harga, detail.Subtotal, tt.harga[i])
+				}
+				if detail.IDBarang == "" {
+					t.Errorf("line %d has no id_barang", i)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTransaksiInvalid(t *testing.T) {
+	stored := entity.TransaksiHeader{IDTrans: "TR-1"}
+	barang := map[string]entity.Barang{"BR-1": {Id_barang: "BR-1", Harga: money.New(10000)}}
+
+	tests := []struct {
+		name    string
+		idTrans string
+		header  entity.TransaksiHeader
+		details []entity.TransaksiDetail
+		code    apperror.Code
+	}{
+		{"no lines", "TR-1", entity.TransaksiHeader{}, nil, apperror.CodeValidation},
+		{"zero qty", "TR-1", entity.TransaksiHeader{}, []entity.TransaksiDetail{{IDBarang: "BR-1"}}, apperror.CodeValidation},
+		{"negative qty", "TR-1", entity.TransaksiHeader{}, []entity.TransaksiDetail{{IDBarang: "BR-1", Qty: -1}}, apperror.CodeValidation},
+		{"diskon over subtotal", "TR-1", entity.TransaksiHeader{Diskon: money.New(10001)}, []entity.TransaksiDetail{{IDBarang: "BR-1", Qty: 1}}, apperror.CodeValidation},
+		{"unknown barang", "TR-1", entity.TransaksiHeader{}, []entity.TransaksiDetail{{IDBarang: "BR-9", Qty: 1}}, apperror.CodeNotFound},
+		{"bad barcode", "TR-1", entity.TransaksiHeader{}, []entity.TransaksiDetail{{Barcode: "4006381333932", Qty: 1}}, apperror.CodeValidation},
+		{"unknown transaksi", "TR-9", entity.TransaksiHeader{}, []entity.TransaksiDetail{{IDBarang: "BR-1", Qty: 1}}, apperror.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaksiRepo := &fakeTransaksiRepo{header: stored}
+			uc := NewTransaksiUsecase(transaksiRepo, &fakeBarangRepo{barang: barang}, 11)
+
+			_, _, err := uc.UpdateTransaksiWithDetail(entity.User{}, tt.idTrans, tt.header, tt.details)
+			if !apperror.Is(err, tt.code) {
+				t.Errorf("error = %v, want %s", err, tt.code)
+			}
+			if transaksiRepo.updated {
+				t.Error("the transaksi was updated")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/repository"
+	"roxy/shared/apperror"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserUsecase interface {
+	Create(user entity.User) (entity.User, error)
+	List() ([]entity.User, error)
+	GetByID(id string) (entity.User, error)
+	// Update changes the role, password or aktif of a user, a nil aktif
+	// and an empty role or password keep what the user has.
+	Update(id string, role string, password string, aktif *bool) (entity.User, error)
+	// EnsureAdmin creates the first admin while there are no users yet.
+	EnsureAdmin(username, password string) error
+}
+
+type userUsecase struct {
+	userRepo repository.UserRepository
+}
+
+func (u *userUsecase) Create(user entity.User) (entity.User, error) {
+	user.Username = strings.TrimSpace(user.Username)
+
+	var fields apperror.FieldErrors
+	if user.Username == "" || len(user.Username) > 30 {
+		fields.Add("username", "username must be 1 to 30 characters")
+	}
+	if !slices.Contains(entity.Roles, user.Role) {
+		fields.Add("role", "role must be one of %s", strings.Join(entity.Roles, ", "))
+	}
+	if msg := checkPassword(user.Password); msg != "" {
+		fields.Add("password", "%s", msg)
+	}
+	if err := fields.Err(); err != nil {
+		return entity.User{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return entity.User{}, err
+	}
+	user.PasswordHash = string(hash)
+	user.Password = ""
+	user.Aktif = true
+
+	return u.userRepo.Create(user)
+}
+
+func (u *userUsecase) List() ([]entity.User, error) {
+	return u.userRepo.List()
+}
+
+func (u *userUsecase) GetByID(id string) (entity.User, error) {
+	return u.userRepo.GetByID(id)
+}
+
+func (u *userUsecase) Update(id string, role string, password string, aktif *bool) (entity.User, error) {
+	user, err := u.userRepo.GetByID(id)
+	if err != nil {
+		return entity.User{}, err
+	}
+	wasAdmin := user.Aktif && user.Role == entity.RoleAdmin
+
+	var fields apperror.FieldErrors
+	if role != "" {
+		if !slices.Contains(entity.Roles, role) {
+			fields.Add("role", "role must be one of %s", strings.Join(entity.Roles, ", "))
+		}
+		user.Role = role
+	}
+	if password != "" {
+		if msg := checkPassword(password); msg != "" {
+			fields.Add("password", "%s", msg)
+		}
+	}
+	if err := fields.Err(); err != nil {
+		return entity.User{}, err
+	}
+
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return entity.User{}, err
+		}
+		user.PasswordHash = string(hash)
+	}
+	if aktif != nil {
+		user.Aktif = *aktif
+	}
+	if wasAdmin && (!user.Aktif || user.Role != entity.RoleAdmin) {
+		if err := u.keepOneAdmin(user.IDUser); err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	return u.userRepo.Update(user)
+}
+
+// keepOneAdmin refuses to take away the last active admin.
+func (u *userUsecase) keepOneAdmin(idUser string) error {
+	users, err := u.userRepo.List()
+	if err != nil {
+		return err
+	}
+	for _, other := range users {
+		if other.IDUser != idUser && other.Aktif && other.Role == entity.RoleAdmin {
+			return nil
+		}
+	}
+	return apperror.Conflict("user %s is the last active admin", idUser)
+}
+
+func (u *userUsecase) EnsureAdmin(username, password string) error {
+	count, err := u.userRepo.Count()
+	if err != nil || count > 0 {
+		return err
+	}
+	if username == "" || password == "" {
+		return apperror.Validation("there are no users yet, set ADMIN_USERNAME and ADMIN_PASSWORD to create the first admin")
+	}
+
+	_, err = u.Create(entity.User{Username: username, Password: password, Role: entity.RoleAdmin})
+	return err
+}
+
+// checkPassword returns what is wrong with the password, if anything. bcrypt
+// only looks at the first 72 bytes.
+func checkPassword(password string) string {
+	if len(password) < 8 || len(password) > 72 {
+		return "password must be 8 to 72 characters"
+	}
+	return ""
+}
+
+func NewUserUsecase(userRepo repository.UserRepository) UserUsecase {
+	return &userUsecase{userRepo: userRepo}
+}
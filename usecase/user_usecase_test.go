@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"roxy/entity"
+	"roxy/shared/apperror"
+	"testing"
+)
+
+// fakeUserRepo keeps users in memory in the order they were added.
+type fakeUserRepo struct {
+	users []entity.User
+}
+
+func (f *fakeUserRepo) Create(user entity.User) (entity.User, error) {
+	f.users = append(f.users, user)
+	return user, nil
+}
+
+func (f *fakeUserRepo) List() ([]entity.User, error) {
+	return append([]entity.User(nil), f.users...), nil
+}
+
+func (f *fakeUserRepo) Count() (int, error) {
+	return len(f.users), nil
+}
+
+func (f *fakeUserRepo) GetByID(id string) (entity.User, error) {
+	for _, user := range f.users {
+		if user.IDUser == id {
+			return user, nil
+		}
+	}
+	return entity.User{}, apperror.NotFound("user %s not found", id)
+}
+
+func (f *fakeUserRepo) GetByUsername(username string) (entity.User, error) {
+	for _, user := range f.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return entity.User{}, apperror.NotFound("user %s not found", username)
+}
+
+func (f *fakeUserRepo) Update(user entity.User) (entity.User, error) {
+	for i := range f.users {
+		if f.users[i].IDUser == user.IDUser {
+			f.users[i] = user
+			return user, nil
+		}
+	}
+	return entity.User{}, apperror.NotFound("user %s not found", user.IDUser)
+}
+
+func (f *fakeUserRepo) SaveRefreshToken(token entity.RefreshToken) error { return nil }
+
+func (f *fakeUserRepo) RotateRefreshToken(tokenHash string, next entity.RefreshToken) (entity.User, error) {
+	return entity.User{}, apperror.Unauthorized("refresh token tidak valid")
+}
+
+func (f *fakeUserRepo) RevokeRefreshToken(tokenHash string) error { return nil }
+
+func TestUserUpdateKeepsLastAdmin(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name     string
+		others   []entity.User
+		role     string
+		aktif    *bool
+		conflict bool
+	}{
+		{name: "demote last admin", role: entity.RoleSupervisor, conflict: true},
+		{name: "demote last admin to kasir", role: entity.RoleKasir, conflict: true},
+		{name: "deactivate last admin", aktif: &no, conflict: true},
+		{name: "demote and deactivate last admin", role: entity.RoleKasir, aktif: &no, conflict: true},
+		{
+			name:     "other admin is inactive",
+			others:   []entity.User{{IDUser: "2", Role: entity.RoleAdmin, Aktif: false}},
+			role:     entity.RoleKasir,
+			conflict: true,
+		},
+		{
+			name:     "other active user is no admin",
+			others:   []entity.User{{IDUser: "2", Role: entity.RoleSupervisor, Aktif: true}},
+			aktif:    &no,
+			conflict: true,
+		},
+		{
+			name:   "demote with another admin",
+			others: []entity.User{{IDUser: "2", Role: entity.RoleAdmin, Aktif: true}},
+			role:   entity.RoleSupervisor,
+		},
+		{
+			name:   "deactivate with another admin",
+			others: []entity.User{{IDUser: "2", Role: entity.RoleAdmin, Aktif: true}},
+			aktif:  &no,
+		},
+		{name: "keep role", role: entity.RoleAdmin, aktif: &yes},
+		{name: "nothing changes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			admin := entity.User{IDUser: "1", Username: "admin", Role: entity.RoleAdmin, Aktif: true}
+			repo := &fakeUserRepo{users: append([]entity.User{admin}, tt.others...)}
+			users := NewUserUsecase(repo)
+
+			_, err := users.Update(admin.IDUser, tt.role, "", tt.aktif)
+			if tt.conflict {
+				if !apperror.Is(err, apperror.CodeConflict) {
+					t.Fatalf("Update error = %v, want a conflict", err)
+				}
+				if stored, _ := repo.GetByID(admin.IDUser); stored != admin {
+					t.Errorf("stored user = %+v, want it unchanged", stored)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update returned error %v", err)
+			}
+		})
+	}
+}
+
+func TestUserUpdateInactiveAdmin(t *testing.T) {
+	// An admin that is already inactive is not the one keeping the shop
+	// running, demoting them needs no other admin.
+	repo := &fakeUserRepo{users: []entity.User{{IDUser: "1", Role: entity.RoleAdmin, Aktif: false}}}
+	user, err := NewUserUsecase(repo).Update("1", entity.RoleKasir, "", nil)
+	if err != nil {
+		t.Fatalf("Update returned error %v", err)
+	}
+	if user.Role != entity.RoleKasir {
+		t.Errorf("role = %q, want %q", user.Role, entity.RoleKasir)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"roxy/app"
+	"roxy/entity"
+	"strings"
+)
+
+// runCreateUser creates a user from the command line, which is also how an
+// admin gets back in after losing the last admin password. The password is
+// the first line of stdin so it stays out of the shell history.
+func runCreateUser(args []string) error {
+	fs := newFlagSet("create-user")
+	role := fs.String("role", entity.RoleKasir, "one of "+strings.Join(entity.Roles, ", "))
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: roxy create-user [-role role] <username>")
+	}
+
+	a, err := app.New()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	fmt.Fprint(os.Stderr, "password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return errors.New("no password given on stdin")
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	user, err := a.UserUc.Create(entity.User{Username: fs.Arg(0), Password: password, Role: *role})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s user %s (%s)\n", user.Role, user.Username, user.IDUser)
+	return nil
+}